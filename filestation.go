@@ -4,7 +4,9 @@ import (
 	"crypto/tls"
 	"fmt"
 	"github.com/go-resty/resty/v2"
+	"net/http"
 	"strings"
+	"sync"
 	"time"
 )
 
@@ -17,14 +19,77 @@ var defaultConfigOptions = ConfigOptions{
 type ConfigOptions struct {
 	APICallTimeout              time.Duration
 	IgnoreInvalidSSLCertificate bool
+
+	// PasswordEncoding overrides the default (base64)
+	// encoding of the password during login.
+	PasswordEncoding PasswordEncoding
+
+	// LegacyGETLogin permits sending the credentials as query parameters,
+	// if the server does not accept them as form data. This is required by
+	// old firmware, but exposes the credentials in access logs. The fallback
+	// is used if the form login is answered by HTTP 405 or fails.
+	LegacyGETLogin bool
+
+	// RememberPassword keeps the password in memory for the lifetime of the
+	// session, so expired sessions can be renewed without a login token,
+	// see CapabilityQToken. By default, the password is discarded after login.
+	RememberPassword bool
+
+	// KeepAliveInterval enables a background call in the given interval,
+	// which keeps the session alive while being idle. Zero disables it.
+	KeepAliveInterval time.Duration
+
+	// KeepAliveErrorHandler is called whenever a keepalive call fails.
+	KeepAliveErrorHandler func(error)
+
+	// RetryPolicy enables retries of temporarily failed API calls, if set.
+	RetryPolicy *RetryPolicy
+
+	// Logger receives one line per API request, if set.
+	// Credentials and session IDs are redacted.
+	Logger Logger
+
+	// Transport replaces the HTTP transport, e.g. for recording and
+	// replaying requests, see package filestationtest.
+	// IgnoreInvalidSSLCertificate does not apply to a custom transport,
+	// so it must skip the verification itself, if required:
+	//
+	//	transport := http.DefaultTransport.(*http.Transport).Clone()
+	//	transport.TLSClientConfig = &tls.Config{InsecureSkipVerify: true}
+	Transport http.RoundTripper
+}
+
+// Logger is the interface used for request logging.
+// It is implemented by *log.Logger.
+type Logger interface {
+	Printf(format string, v ...interface{})
 }
 
 // FileStationSession is a container for our session state.
+// It is safe for concurrent use by multiple goroutines.
 type FileStationSession struct {
-	host      string
-	sessionID string
-	conn      *resty.Client
-	options   *ConfigOptions
+	host    string
+	conn    *resty.Client
+	options *ConfigOptions
+
+	// mu guards the session state below
+	mu         sync.RWMutex
+	sessionID  string
+	serverInfo ServerInfo
+	postLogin  bool
+	username   string
+	password   string
+	qtoken     string
+
+	// recycleBins caches the recycle bin setting by share, see Delete()
+	recycleBins map[string]bool
+
+	// renewMu serializes the renewal of expired sessions
+	renewMu sync.Mutex
+
+	keepAliveStop chan struct{}
+	keepAliveDone chan struct{}
+	keepAliveOnce sync.Once // stops the keepalive only once
 }
 
 // String returns the session's hostname.
@@ -33,6 +98,10 @@ func (s *FileStationSession) String() string {
 }
 
 // Connect sets up our connection to the QNAP system.
+//
+// Expired sessions are renewed by the login token issued on QTS 5.x.
+// The password is only kept in memory for renewal if enabled by
+// ConfigOptions.RememberPassword.
 func Connect(host, username, password string, configOptions *ConfigOptions) (*FileStationSession, error) {
 	if !strings.HasPrefix(host, "http") {
 		host = fmt.Sprintf("https://%s", host)
@@ -48,21 +117,39 @@ func Connect(host, username, password string, configOptions *ConfigOptions) (*Fi
 		options: configOptions,
 	}
 
-	// setup SSL certificate handling
-	if configOptions.IgnoreInvalidSSLCertificate {
+	// setup transport and SSL certificate handling
+	if configOptions.Transport != nil {
+		session.conn.SetTransport(configOptions.Transport)
+	} else if configOptions.IgnoreInvalidSSLCertificate {
 		session.conn.SetTLSClientConfig(&tls.Config{InsecureSkipVerify: true})
 	}
 
+	// setup request logging
+	if configOptions.Logger != nil {
+		session.conn.OnAfterResponse(func(c *resty.Client, res *resty.Response) error {
+			configOptions.Logger.Printf("filestation: %v %v: %v (%v)", res.Request.Method, redactURL(res.Request.RawRequest.URL), res.Status(), res.Time())
+			return nil
+		})
+	}
+
 	// perform login
 	err := session.Login(username, password)
 	if err != nil {
 		return nil, err
 	}
 
+	// keep the session alive
+	if configOptions.KeepAliveInterval > 0 {
+		session.startKeepAlive(configOptions.KeepAliveInterval)
+	}
+
 	// done
 	return session, nil
 }
 
+// Close stops the keepalive, if enabled, and invalidates the session.
 func (s *FileStationSession) Close() error {
+	s.stopKeepAlive()
+
 	return s.Logout()
 }
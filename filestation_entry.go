@@ -0,0 +1,109 @@
+package filestation
+
+import (
+	"fmt"
+	"io/fs"
+	"time"
+)
+
+// FileType is the media type of a file, as reported in the filetype
+// field of the File Station API. The values are the ones used by the
+// File Station web interface. Values not listed here are reported as
+// FileType_Unknown by FileListEntry.Kind().
+type FileType int
+
+const (
+	FileType_Unknown FileType = -1 // not a known value of the filetype field
+	FileType_Other   FileType = 0  // any other file, or a folder
+	FileType_Music   FileType = 1  // audio file
+	FileType_Video   FileType = 2  // video file
+	FileType_Photo   FileType = 3  // image file
+)
+
+func (t FileType) String() string {
+	switch t {
+	case FileType_Unknown:
+		return "Unknown"
+	case FileType_Other:
+		return "Other"
+	case FileType_Music:
+		return "Music"
+	case FileType_Video:
+		return "Video"
+	case FileType_Photo:
+		return "Photo"
+	}
+
+	return fmt.Sprintf("FileType(%v)", int(t))
+}
+
+// Existing checks if the file or folder exists.
+func (e *FileListEntry) Existing() bool {
+	return e.Exists != 0
+}
+
+// IsDir checks if the entry is a folder.
+func (e *FileListEntry) IsDir() bool {
+	return e.IsFolder != 0
+}
+
+// Compressed checks if the file is an archive.
+func (e *FileListEntry) Compressed() bool {
+	return e.IsCompressed != 0
+}
+
+// Sticky checks if the sticky bit is set.
+func (e *FileListEntry) Sticky() bool {
+	return e.HasStickyBit != 0
+}
+
+// Encrypted checks if the folder is encrypted.
+func (e *FileListEntry) Encrypted() bool {
+	return e.IsFolderEncrypted != 0
+}
+
+// Size returns the size of the file in bytes.
+func (e *FileListEntry) Size() int64 {
+	return e.FileSize
+}
+
+// ModTime returns the time of the last modification.
+func (e *FileListEntry) ModTime() time.Time {
+	return time.Unix(int64(e.ModifiedDate), 0)
+}
+
+// Perm returns the file-system level access privilege.
+func (e *FileListEntry) Perm() Privilege {
+	return NewPrivilegeFromOctal(e.Privilege)
+}
+
+// Mode returns the file mode, including the permission bits
+// and the sticky bit.
+func (e *FileListEntry) Mode() fs.FileMode {
+	mode := fs.FileMode(e.Perm()) & fs.ModePerm
+	if e.IsDir() {
+		mode |= fs.ModeDir
+	}
+	if e.Sticky() {
+		mode |= fs.ModeSticky
+	}
+	return mode
+}
+
+// Kind returns the media type of the file. An unknown value of the
+// FileType field is returned as FileType_Unknown.
+func (e *FileListEntry) Kind() FileType {
+	switch t := FileType(e.FileType); t {
+	case FileType_Other, FileType_Music, FileType_Video, FileType_Photo:
+		return t
+	}
+
+	return FileType_Unknown
+}
+
+// Info returns the entry as fs.FileInfo. FileListEntry cannot implement
+// fs.FileInfo directly, as its Name field conflicts with the Name() method,
+// and renaming the field would break existing users.
+func (e *FileListEntry) Info() fs.FileInfo {
+	return &fileInfo{entry: e}
+}
@@ -0,0 +1,66 @@
+package filestation
+
+import (
+	"io/fs"
+	"testing"
+	"time"
+)
+
+func TestFileListEntryAccessors(t *testing.T) {
+	e := &FileListEntry{
+		Name:              "movie.mp4",
+		Exists:            1,
+		FileSize:          1024,
+		Privilege:         "1644",
+		HasStickyBit:      1,
+		IsFolderEncrypted: 0,
+		FileType:          2,
+		ModifiedDate:      1600000000,
+	}
+
+	if !e.Existing() || e.IsDir() || e.Encrypted() || !e.Sticky() {
+		t.Fatalf("wrong flags: %+v", e)
+	}
+	if e.Size() != 1024 {
+		t.Fatalf("wrong size: %v", e.Size())
+	}
+	if !e.ModTime().Equal(time.Unix(1600000000, 0)) {
+		t.Fatalf("wrong modification time: %v", e.ModTime())
+	}
+	if e.Perm() != 01644 {
+		t.Fatalf("wrong privilege: %v", e.Perm())
+	}
+	if e.Mode() != fs.ModeSticky|0644 {
+		t.Fatalf("wrong mode: %v", e.Mode())
+	}
+
+	if e.Kind() != FileType_Video || e.Kind().String() != "Video" {
+		t.Fatalf("wrong file type: %v", e.Kind())
+	}
+
+	info := e.Info()
+	if info.Name() != "movie.mp4" || info.Size() != 1024 || info.Sys() != e {
+		t.Fatalf("wrong file info: %v", info)
+	}
+}
+
+func TestFileListEntryKind(t *testing.T) {
+	tests := []struct {
+		fileType int
+		kind     FileType
+	}{
+		{0, FileType_Other},
+		{1, FileType_Music},
+		{2, FileType_Video},
+		{3, FileType_Photo},
+		{4, FileType_Unknown},
+		{-1, FileType_Unknown},
+	}
+
+	for _, test := range tests {
+		e := &FileListEntry{FileType: test.fileType}
+		if e.Kind() != test.kind {
+			t.Errorf("wrong file type of %v: %v", test.fileType, e.Kind())
+		}
+	}
+}
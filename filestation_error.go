@@ -0,0 +1,230 @@
+package filestation
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"github.com/go-resty/resty/v2"
+	"io"
+	"strings"
+)
+
+// maxErrorBodyLength limits the response body kept in an Error.
+const maxErrorBodyLength = 512
+
+// Error describes a failed API call.
+// The underlying cause can be checked by using errors.Is() and errors.As(),
+// e.g. errors.Is(err, WFM2_PERMISSION_DENY).
+type Error struct {
+	Op          string            // operation, e.g. "CreateFolder"
+	Path        string            // remote path, if any
+	HTTPStatus  int               // HTTP status code, if a response has been received
+	ContentType string            // content type of the response, if any
+	Status      FileStationStatus // status code, only valid if Err is a FileStationStatus
+	Message     string            // human-readable message of the status, if any
+	Body        string            // truncated response body, if any
+	Err         error             // underlying cause
+}
+
+func (e *Error) Error() string {
+	msg := e.Op
+	if e.Path != "" {
+		msg += " " + e.Path
+	}
+
+	if e.Message != "" {
+		return fmt.Sprintf("%v: %v: %v", msg, e.Err, e.Message)
+	}
+
+	return fmt.Sprintf("%v: %v", msg, e.Err)
+}
+
+// Unwrap returns the underlying cause.
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// newError creates an error for the failed operation.
+// The response is optional.
+func newError(op, path string, res *resty.Response, err error) *Error {
+	e := &Error{
+		Op:   op,
+		Path: path,
+		Err:  err,
+	}
+
+	if status, ok := err.(FileStationStatus); ok {
+		e.Status = status
+		e.Message = status.Message(LanguageEnglish)
+	}
+
+	if res != nil && res.RawResponse != nil {
+		e.HTTPStatus = res.StatusCode()
+		e.ContentType = res.Header().Get("Content-Type")
+		e.Body = truncateBody(res.Body())
+	}
+
+	return e
+}
+
+func truncateBody(body []byte) string {
+	if len(body) > maxErrorBodyLength {
+		return string(body[:maxErrorBodyLength]) + "..."
+	}
+	return string(body)
+}
+
+// execute performs the request and converts transport failures,
+// non-JSON responses and unexpected HTTP status codes into *Error.
+// Temporary failures are retried according to ConfigOptions.RetryPolicy.
+// An expired session is renewed by Relogin() and the request is repeated once.
+func (s *FileStationSession) execute(op, path string, req *resty.Request, method, url string) (*resty.Response, error) {
+	return s.executeStream(op, path, req, method, url, nil)
+}
+
+// executeStream performs a request with a streamed body, see execute().
+// As the body is consumed by sending it, the request is only repeated
+// if the body implements io.Seeker.
+func (s *FileStationSession) executeStream(op, path string, req *resty.Request, method, url string, body io.Reader) (*resty.Response, error) {
+	return s.executeWith(op, path, req, url, rewinder(body), func() (*resty.Response, error) {
+		return s.executeOnce(op, path, req, method, url)
+	})
+}
+
+// executeWith performs the request by a function, which makes a single
+// attempt. Retries and the renewal of an expired session are applied as
+// by execute().
+func (s *FileStationSession) executeWith(op, path string, req *resty.Request, url string, rewind func() bool, once func() (*resty.Response, error)) (*resty.Response, error) {
+	sessionID := s.currentSessionID()
+	if sessionID != "" {
+		req.SetQueryParam("sid", sessionID)
+	}
+
+	res, err := s.executeWithRetry(op, path, req, rewind, once)
+
+	if strings.HasSuffix(url, "utilRequest.cgi") && isSessionExpired(res, err) && rewind() {
+		if rerr := s.renewSession(sessionID); rerr == nil {
+			req.SetQueryParam("sid", s.currentSessionID())
+			resetResult(req)
+			res, err = s.executeWithRetry(op, path, req, rewind, once)
+		}
+	}
+
+	return res, err
+}
+
+func (s *FileStationSession) executeWithRetry(op, path string, req *resty.Request, rewind func() bool, once func() (*resty.Response, error)) (*resty.Response, error) {
+	policy := s.options.RetryPolicy
+	if policy == nil || (!policy.RetryMutating && !idempotentFuncs[req.QueryParam.Get("func")]) {
+		return once()
+	}
+
+	for attempt := 1; ; attempt++ {
+		res, err := once()
+		if attempt >= policy.MaxAttempts {
+			return res, err
+		}
+
+		// check for temporary failures
+		if err == nil {
+			status, ok := statusOf(res)
+			if !ok || !policy.isTemporary(newError(op, path, res, status)) {
+				return res, nil
+			}
+		} else if !policy.isTemporary(err) {
+			return res, err
+		}
+
+		// wait before the next attempt
+		if !sleep(req, policy.backoff(attempt)) || !rewind() {
+			return res, err
+		}
+
+		resetResult(req)
+	}
+}
+
+func (s *FileStationSession) executeOnce(op, path string, req *resty.Request, method, url string) (*resty.Response, error) {
+	res, err := req.Execute(method, url)
+	if err != nil {
+		// status responses do not match the expected result type
+		if res != nil && res.RawResponse != nil && res.StatusCode() == 200 {
+			if status, ok := statusOf(res); ok {
+				return nil, newError(op, path, res, status)
+			}
+			if err := checkJSON(res); err != nil {
+				return nil, newError(op, path, res, err)
+			}
+		}
+
+		return nil, newError(op, path, res, fmt.Errorf("failed to perform request: %w", err))
+	}
+	if res.StatusCode() != 200 {
+		if err := checkJSON(res); errors.Is(err, ErrSessionExpired) {
+			return nil, newError(op, path, res, err)
+		}
+		return nil, newError(op, path, res, fmt.Errorf("failed to perform request: unexpected HTTP status code: %v", res.StatusCode()))
+	}
+	if req.Result != nil {
+		if err := checkJSON(res); err != nil {
+			return nil, newError(op, path, res, err)
+		}
+	}
+
+	return res, nil
+}
+
+var (
+	// ErrNotJSON is returned if the server responded with something else
+	// than JSON, e.g. an HTML page of a proxy.
+	ErrNotJSON = errors.New("unexpected non-JSON response")
+
+	// ErrSessionExpired is returned if the session is no longer valid,
+	// e.g. because the server responded with its login page.
+	ErrSessionExpired = errors.New("session expired")
+)
+
+// sessionExpiredMarkers identify the pages returned by QTS
+// if the session is no longer valid (in lower case).
+var sessionExpiredMarkers = []string{
+	"session expired",
+	"session timeout",
+	"cgi-bin/login.html",
+	"authlogin.cgi",
+}
+
+// checkJSON makes sure the response body contains JSON.
+func checkJSON(res *resty.Response) error {
+	body := bytes.TrimSpace(res.Body())
+	contentType := res.Header().Get("Content-Type")
+
+	if len(body) > 0 && (body[0] == '{' || body[0] == '[') {
+		return nil
+	}
+	if len(body) >= 2 && body[0] == 0x1f && body[1] == 0x8b {
+		return fmt.Errorf("%w: gzip-compressed content (content type %q)", ErrNotJSON, contentType)
+	}
+
+	lower := strings.ToLower(string(body))
+	for _, m := range sessionExpiredMarkers {
+		if strings.Contains(lower, m) {
+			return fmt.Errorf("%w: login page received (content type %q)", ErrSessionExpired, contentType)
+		}
+	}
+
+	if len(body) == 0 {
+		return fmt.Errorf("%w: empty body (content type %q)", ErrNotJSON, contentType)
+	}
+
+	return fmt.Errorf("%w: content type %q", ErrNotJSON, contentType)
+}
+
+// isSessionExpired checks if the request failed because of an invalid session.
+func isSessionExpired(res *resty.Response, err error) bool {
+	if err != nil {
+		return errors.Is(err, ErrSessionExpired) || errors.Is(err, WFM2_AUTH_FAIL)
+	}
+
+	status, ok := statusOf(res)
+	return ok && status == WFM2_AUTH_FAIL
+}
@@ -0,0 +1,93 @@
+package filestation
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestErrorUnwrap(t *testing.T) {
+	var err error = newError("CreateFolder", "/share/test", nil, WFM2_PERMISSION_DENY)
+
+	if !errors.Is(err, WFM2_PERMISSION_DENY) {
+		t.Fatal("expected error to match status")
+	}
+	if errors.Is(err, WFM2_FILE_NO_EXIST) {
+		t.Fatal("expected error to not match other status")
+	}
+
+	var e *Error
+	if !errors.As(err, &e) {
+		t.Fatal("expected error to be *Error")
+	}
+	if e.Op != "CreateFolder" || e.Path != "/share/test" || e.Status != WFM2_PERMISSION_DENY {
+		t.Fatalf("wrong error details: %+v", e)
+	}
+	if err.Error() != "CreateFolder /share/test: WFM2_PERMISSION_DENY: Permission denied." {
+		t.Fatalf("wrong error message: %v", err)
+	}
+}
+
+func TestErrorTruncateBody(t *testing.T) {
+	body := make([]byte, maxErrorBodyLength*2)
+	for i := range body {
+		body[i] = 'x'
+	}
+
+	if len(truncateBody(body)) != maxErrorBodyLength+3 {
+		t.Fatal("expected body to be truncated")
+	}
+	if truncateBody([]byte("short")) != "short" {
+		t.Fatal("expected short body to be kept")
+	}
+}
+
+func createHTMLTestSession(t *testing.T, page string, pages int32) (*FileStationSession, *int32) {
+	var calls int32
+
+	s, stub := createStubSession(t, &ConfigOptions{
+		APICallTimeout:   5 * time.Second,
+		RememberPassword: true,
+	}, func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) <= pages {
+			w.Header().Set("Content-Type", "text/html")
+			w.Write([]byte(page))
+			return
+		}
+		w.Write([]byte(`[{"id":"/share"}]`))
+	})
+
+	return s, &stub.logins
+}
+
+func TestErrorNotJSON(t *testing.T) {
+	s, _ := createHTMLTestSession(t, "<html><body>Access denied by proxy</body></html>", 1)
+
+	_, err := s.GetShareList()
+	if !errors.Is(err, ErrNotJSON) {
+		t.Fatalf("Expected non-JSON error, got %v", err)
+	}
+
+	var e *Error
+	if !errors.As(err, &e) || e.ContentType != "text/html" || !strings.Contains(e.Body, "Access denied") {
+		t.Fatalf("Expected content type and body in error: %+v", e)
+	}
+}
+
+func TestErrorSessionExpiredRelogin(t *testing.T) {
+	s, logins := createHTMLTestSession(t, `<html><script>location.href="/cgi-bin/login.html"</script></html>`, 1)
+
+	shares, err := s.GetShareList()
+	if err != nil {
+		t.Fatalf("Failed to retrieve share list: %v", err)
+	}
+	if len(shares) != 1 {
+		t.Fatalf("Expected one share, got %v", len(shares))
+	}
+	if atomic.LoadInt32(logins) < 2 {
+		t.Fatal("Expected a re-login")
+	}
+}
@@ -0,0 +1,315 @@
+package filestation
+
+import (
+	"io"
+	"io/fs"
+	"path"
+	"sort"
+	"time"
+)
+
+// FS provides read-only access to a share or folder of the QNAP system.
+// It implements fs.FS, fs.StatFS, fs.ReadDirFS and fs.ReadFileFS,
+// so it can be used with fs.WalkDir(), fs.Glob(), http.FS() and others.
+// Opened files implement io.Seeker, as required by http.FS().
+type FS struct {
+	session FileStation
+	root    string
+	err     error // invalid root
+}
+
+var (
+	_ fs.StatFS     = (*FS)(nil)
+	_ fs.ReadDirFS  = (*FS)(nil)
+	_ fs.ReadFileFS = (*FS)(nil)
+)
+
+// NewFS creates a file system rooted at the given share or folder,
+// e.g. "/Public" or "/Public/documents". The root is cleaned by
+// CleanPath(). If it is invalid, all operations fail with ErrInvalidPath.
+func NewFS(session FileStation, root string) *FS {
+	clean, err := CleanPath(root)
+
+	return &FS{
+		session: session,
+		root:    clean,
+		err:     err,
+	}
+}
+
+// remotePath converts the name into a path on the QNAP system.
+func (f *FS) remotePath(op, name string) (string, error) {
+	if f.err != nil {
+		return "", &fs.PathError{Op: op, Path: name, Err: f.err}
+	}
+	if !fs.ValidPath(name) {
+		return "", &fs.PathError{Op: op, Path: name, Err: fs.ErrInvalid}
+	}
+
+	return path.Join(f.root, name), nil
+}
+
+// Open opens the named file or folder.
+func (f *FS) Open(name string) (fs.File, error) {
+	info, err := f.Stat(name)
+	if err != nil {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: unwrapPathError(err)}
+	}
+
+	if info.IsDir() {
+		return &dirFile{fs: f, name: name, info: info}, nil
+	}
+
+	return &file{fs: f, name: name, info: info}, nil
+}
+
+// Stat returns the information about the named file or folder.
+func (f *FS) Stat(name string) (fs.FileInfo, error) {
+	p, err := f.remotePath("stat", name)
+	if err != nil {
+		return nil, err
+	}
+
+	if name == "." {
+		return f.statRoot()
+	}
+
+	entry, err := f.session.GetFileStat(p)
+	if err != nil {
+		return nil, &fs.PathError{Op: "stat", Path: name, Err: err}
+	}
+	if entry == nil {
+		return nil, &fs.PathError{Op: "stat", Path: name, Err: fs.ErrNotExist}
+	}
+
+	return &fileInfo{entry: entry}, nil
+}
+
+// statRoot checks that the root exists and is a folder. Shares are
+// looked up in the share list, other folders are stat'ed.
+func (f *FS) statRoot() (fs.FileInfo, error) {
+	if path.Dir(f.root) == "/" {
+		shares, err := f.session.GetShareList()
+		if err != nil {
+			return nil, &fs.PathError{Op: "stat", Path: ".", Err: err}
+		}
+		for _, share := range shares {
+			if share.Path == f.root {
+				return &fileInfo{entry: &FileListEntry{Name: path.Base(f.root), FullPath: f.root, IsFolder: 1, Exists: 1}}, nil
+			}
+		}
+
+		return nil, &fs.PathError{Op: "stat", Path: ".", Err: fs.ErrNotExist}
+	}
+
+	entry, err := f.session.GetFileStat(f.root)
+	if err != nil {
+		return nil, &fs.PathError{Op: "stat", Path: ".", Err: err}
+	}
+	if entry == nil || !entry.IsDir() {
+		return nil, &fs.PathError{Op: "stat", Path: ".", Err: fs.ErrNotExist}
+	}
+
+	return &fileInfo{entry: entry}, nil
+}
+
+// ReadDir reads the named folder and returns its entries sorted by name.
+func (f *FS) ReadDir(name string) ([]fs.DirEntry, error) {
+	p, err := f.remotePath("readdir", name)
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := f.session.GetFileList(p)
+	if err != nil {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: err}
+	}
+
+	ret := make([]fs.DirEntry, len(entries))
+	for i := range entries {
+		ret[i] = &dirEntry{info: &fileInfo{entry: &entries[i]}}
+	}
+
+	sort.Slice(ret, func(i, j int) bool { return ret[i].Name() < ret[j].Name() })
+
+	return ret, nil
+}
+
+// ReadFile reads the whole content of the named file.
+func (f *FS) ReadFile(name string) ([]byte, error) {
+	p, err := f.remotePath("readfile", name)
+	if err != nil {
+		return nil, err
+	}
+
+	r, err := f.session.Download(p)
+	if err != nil {
+		return nil, &fs.PathError{Op: "readfile", Path: name, Err: err}
+	}
+	defer r.Close()
+
+	return io.ReadAll(r)
+}
+
+func unwrapPathError(err error) error {
+	if pe, ok := err.(*fs.PathError); ok {
+		return pe.Err
+	}
+	return err
+}
+
+// fileInfo adapts a FileListEntry to fs.FileInfo.
+type fileInfo struct {
+	entry *FileListEntry
+}
+
+func (i *fileInfo) Name() string       { return i.entry.Name }
+func (i *fileInfo) Size() int64        { return i.entry.Size() }
+func (i *fileInfo) ModTime() time.Time { return i.entry.ModTime() }
+func (i *fileInfo) IsDir() bool        { return i.entry.IsDir() }
+func (i *fileInfo) Mode() fs.FileMode  { return i.entry.Mode() }
+func (i *fileInfo) Sys() interface{}   { return i.entry }
+
+// dirEntry adapts a FileListEntry to fs.DirEntry.
+type dirEntry struct {
+	info *fileInfo
+}
+
+func (d *dirEntry) Name() string               { return d.info.Name() }
+func (d *dirEntry) IsDir() bool                { return d.info.IsDir() }
+func (d *dirEntry) Type() fs.FileMode          { return d.info.Mode().Type() }
+func (d *dirEntry) Info() (fs.FileInfo, error) { return d.info, nil }
+
+// file is an opened file, which is downloaded on the first read.
+// Seeking restarts the download at the new offset.
+type file struct {
+	fs     *FS
+	name   string
+	info   fs.FileInfo
+	reader io.ReadCloser
+	pos    int64 // position of the reader
+	offset int64 // position of the next read
+}
+
+var _ io.ReadSeeker = (*file)(nil)
+
+func (f *file) Stat() (fs.FileInfo, error) { return f.info, nil }
+
+func (f *file) Read(b []byte) (int, error) {
+	// restart the download after seeking
+	if f.reader != nil && f.pos != f.offset {
+		f.reader.Close()
+		f.reader = nil
+	}
+
+	if f.reader == nil {
+		p, err := f.fs.remotePath("read", f.name)
+		if err != nil {
+			return 0, err
+		}
+
+		r, err := f.fs.session.Download(p)
+		if err != nil {
+			return 0, &fs.PathError{Op: "read", Path: f.name, Err: err}
+		}
+		f.reader = r
+
+		// skip the content before the offset
+		n, err := io.CopyN(io.Discard, r, f.offset)
+		f.pos = n
+		if err != nil {
+			if err == io.EOF {
+				return 0, io.EOF
+			}
+			return 0, &fs.PathError{Op: "read", Path: f.name, Err: err}
+		}
+	}
+
+	n, err := f.reader.Read(b)
+	f.pos += int64(n)
+	f.offset = f.pos
+
+	return n, err
+}
+
+// Seek implements io.Seeker. The end of the file is
+// determined by the size of the opened file.
+func (f *file) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	case io.SeekStart:
+	case io.SeekCurrent:
+		offset += f.offset
+	case io.SeekEnd:
+		offset += f.info.Size()
+	default:
+		return 0, &fs.PathError{Op: "seek", Path: f.name, Err: fs.ErrInvalid}
+	}
+
+	if offset < 0 {
+		return 0, &fs.PathError{Op: "seek", Path: f.name, Err: fs.ErrInvalid}
+	}
+
+	f.offset = offset
+	return offset, nil
+}
+
+func (f *file) Close() error {
+	if f.reader == nil {
+		return nil
+	}
+	return f.reader.Close()
+}
+
+// dirFile is an opened folder.
+type dirFile struct {
+	fs      *FS
+	name    string
+	info    fs.FileInfo
+	entries []fs.DirEntry
+	read    bool
+}
+
+func (d *dirFile) Stat() (fs.FileInfo, error) { return d.info, nil }
+func (d *dirFile) Close() error               { return nil }
+
+func (d *dirFile) Read([]byte) (int, error) {
+	return 0, &fs.PathError{Op: "read", Path: d.name, Err: fs.ErrInvalid}
+}
+
+func (d *dirFile) Write([]byte) (int, error) {
+	return 0, &fs.PathError{Op: "write", Path: d.name, Err: fs.ErrInvalid}
+}
+
+func (d *dirFile) Seek(int64, int) (int64, error) {
+	return 0, &fs.PathError{Op: "seek", Path: d.name, Err: fs.ErrInvalid}
+}
+
+// ReadDir implements fs.ReadDirFile.
+func (d *dirFile) ReadDir(n int) ([]fs.DirEntry, error) {
+	if !d.read {
+		entries, err := d.fs.ReadDir(d.name)
+		if err != nil {
+			return nil, err
+		}
+		d.entries = entries
+		d.read = true
+	}
+
+	if n <= 0 {
+		ret := d.entries
+		d.entries = nil
+		return ret, nil
+	}
+
+	if len(d.entries) == 0 {
+		return nil, io.EOF
+	}
+
+	if n > len(d.entries) {
+		n = len(d.entries)
+	}
+
+	ret := d.entries[:n]
+	d.entries = d.entries[n:]
+	return ret, nil
+}
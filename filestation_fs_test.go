@@ -0,0 +1,159 @@
+package filestation
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFileInfo(t *testing.T) {
+	info := &fileInfo{entry: &FileListEntry{
+		Name:         "test",
+		IsFolder:     1,
+		FileSize:     4096,
+		Privilege:    "755",
+		ModifiedDate: 1600000000,
+	}}
+
+	if !info.IsDir() || info.Mode() != fs.ModeDir|0755 {
+		t.Fatalf("wrong mode: %v", info.Mode())
+	}
+	if !info.ModTime().Equal(time.Unix(1600000000, 0)) {
+		t.Fatalf("wrong modification time: %v", info.ModTime())
+	}
+
+	d := &dirEntry{info: info}
+	if d.Type() != fs.ModeDir || d.Name() != "test" {
+		t.Fatalf("wrong directory entry: %v %v", d.Name(), d.Type())
+	}
+}
+
+func TestFSInvalidPath(t *testing.T) {
+	f := NewFS(nil, "/Public")
+
+	if _, err := f.Open("../etc"); err == nil {
+		t.Fatal("expected invalid path to fail")
+	}
+	if _, err := f.Stat("/absolute"); err == nil {
+		t.Fatal("expected absolute path to fail")
+	}
+
+}
+
+func TestFSRoot(t *testing.T) {
+	m := NewMemoryFileStation()
+	if err := m.AddShare("Public", false); err != nil {
+		t.Fatalf("Failed to create share: %v", err)
+	}
+	if _, err := m.CreateFolder("/Public/folder"); err != nil {
+		t.Fatalf("Failed to create folder: %v", err)
+	}
+	if err := m.Upload("/Public/test.txt", strings.NewReader("test"), false); err != nil {
+		t.Fatalf("Failed to upload: %v", err)
+	}
+
+	for _, root := range []string{"/Public", "/Public/", "/Public/folder/../folder"} {
+		info, err := NewFS(m, root).Stat(".")
+		if err != nil || !info.IsDir() {
+			t.Fatalf("%v: expected root to be a folder: %v", root, err)
+		}
+	}
+
+	for _, root := range []string{"/Missing", "/Public/missing", "/Public/test.txt"} {
+		if _, err := NewFS(m, root).Stat("."); !errors.Is(err, fs.ErrNotExist) {
+			t.Fatalf("%v: expected root not to exist: %v", root, err)
+		}
+		if _, err := NewFS(m, root).Open("."); !errors.Is(err, fs.ErrNotExist) {
+			t.Fatalf("%v: expected open to fail: %v", root, err)
+		}
+	}
+}
+
+func TestFSHTTPFileServer(t *testing.T) {
+	m := NewMemoryFileStation()
+	if err := m.AddShare("Public", false); err != nil {
+		t.Fatalf("Failed to create share: %v", err)
+	}
+	if err := m.Upload("/Public/test.txt", strings.NewReader("hello world"), false); err != nil {
+		t.Fatalf("Failed to upload: %v", err)
+	}
+
+	server := httptest.NewServer(http.FileServer(http.FS(NewFS(m, "/Public"))))
+	defer server.Close()
+
+	get := func(rng string) (int, string) {
+		req, _ := http.NewRequest(http.MethodGet, server.URL+"/test.txt", nil)
+		if rng != "" {
+			req.Header.Set("Range", rng)
+		}
+
+		res, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("Failed to perform request: %v", err)
+		}
+		defer res.Body.Close()
+
+		body, _ := io.ReadAll(res.Body)
+		return res.StatusCode, string(body)
+	}
+
+	if status, body := get(""); status != http.StatusOK || body != "hello world" {
+		t.Fatalf("Wrong response: %v %q", status, body)
+	}
+	if status, body := get("bytes=6-"); status != http.StatusPartialContent || body != "world" {
+		t.Fatalf("Wrong partial response: %v %q", status, body)
+	}
+	if status, body := get("bytes=-5"); status != http.StatusPartialContent || body != "world" {
+		t.Fatalf("Wrong suffix response: %v %q", status, body)
+	}
+}
+
+func TestFSFileSeek(t *testing.T) {
+	m := NewMemoryFileStation()
+	if err := m.AddShare("Public", false); err != nil {
+		t.Fatalf("Failed to create share: %v", err)
+	}
+	if err := m.Upload("/Public/test.txt", strings.NewReader("hello world"), false); err != nil {
+		t.Fatalf("Failed to upload: %v", err)
+	}
+
+	f, err := NewFS(m, "/Public").Open("test.txt")
+	if err != nil {
+		t.Fatalf("Failed to open: %v", err)
+	}
+	defer f.Close()
+
+	r := f.(io.ReadSeeker)
+
+	b := make([]byte, 5)
+	if _, err := io.ReadFull(r, b); err != nil || string(b) != "hello" {
+		t.Fatalf("Wrong content: %q %v", b, err)
+	}
+	if pos, err := r.Seek(-3, io.SeekEnd); err != nil || pos != 8 {
+		t.Fatalf("Failed to seek: %v %v", pos, err)
+	}
+	if rest, err := io.ReadAll(r); err != nil || string(rest) != "rld" {
+		t.Fatalf("Wrong content after seek: %q %v", rest, err)
+	}
+	if _, err := r.Seek(-1, io.SeekStart); err == nil {
+		t.Fatal("Expected negative offset to fail")
+	}
+}
+
+func TestFSInvalidRoot(t *testing.T) {
+	for _, root := range []string{"/", "Public", "/Public/../.."} {
+		f := NewFS(nil, root)
+
+		if _, err := f.Stat("."); !errors.Is(err, ErrInvalidPath) {
+			t.Fatalf("%v: expected invalid root to fail: %v", root, err)
+		}
+		if _, err := f.Open("test.txt"); !errors.Is(err, ErrInvalidPath) {
+			t.Fatalf("%v: expected invalid root to fail: %v", root, err)
+		}
+	}
+}
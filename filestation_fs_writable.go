@@ -0,0 +1,405 @@
+package filestation
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"syscall"
+)
+
+// umask is applied to the permission bits of created files and folders,
+// like the common default of a Unix system.
+const umask fs.FileMode = 022
+
+// File is an opened file of a WritableFS.
+type File interface {
+	fs.File
+	io.Writer
+	io.Seeker
+}
+
+// WritableFS provides read and write access to a share or folder
+// of the QNAP system, following the semantics of the os package.
+//
+// Opened files are kept in memory and uploaded when being closed.
+type WritableFS struct {
+	*FS
+}
+
+// NewWritableFS creates a writable file system rooted at the given
+// share or folder, e.g. "/Public" or "/Public/documents".
+func NewWritableFS(session FileStation, root string) *WritableFS {
+	return &WritableFS{FS: NewFS(session, root)}
+}
+
+// Create creates or truncates the named file.
+func (w *WritableFS) Create(name string) (File, error) {
+	return w.OpenFile(name, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0666)
+}
+
+// OpenFile opens the named file with the given flags (os.O_RDONLY etc.).
+// If the file is created, the permission bits (less umask 022) are applied on close.
+func (w *WritableFS) OpenFile(name string, flag int, perm fs.FileMode) (File, error) {
+	p, err := w.remotePath("open", name)
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := w.Stat(name)
+	if err != nil && !isNotExist(err) {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: unwrapPathError(err)}
+	}
+
+	exists := err == nil
+
+	switch {
+	case exists && info.IsDir() && flag&(os.O_WRONLY|os.O_RDWR) != 0:
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrInvalid}
+	case exists && info.IsDir():
+		return &dirFile{fs: w.FS, name: name, info: info}, nil
+	case exists && flag&(os.O_CREATE|os.O_EXCL) == os.O_CREATE|os.O_EXCL:
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrExist}
+	case !exists && flag&os.O_CREATE == 0:
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+	}
+
+	f := &writableFile{
+		fs:       w,
+		name:     name,
+		path:     p,
+		flag:     flag,
+		perm:     perm,
+		created:  !exists,
+		modified: !exists,
+	}
+
+	// load the existing content
+	if exists && flag&os.O_TRUNC == 0 {
+		content, err := w.ReadFile(name)
+		if err != nil {
+			return nil, err
+		}
+		f.content = content
+	}
+	if exists && flag&os.O_TRUNC != 0 {
+		f.modified = true
+	}
+
+	if flag&os.O_APPEND != 0 {
+		f.offset = int64(len(f.content))
+	}
+
+	return f, nil
+}
+
+// Mkdir creates the named folder. The parent folder must exist.
+// The permission bits (less umask 022) are applied to the folder.
+func (w *WritableFS) Mkdir(name string, perm fs.FileMode) error {
+	p, err := w.remotePath("mkdir", name)
+	if err != nil {
+		return err
+	}
+
+	created, err := w.session.CreateFolder(p)
+	if err != nil {
+		return &fs.PathError{Op: "mkdir", Path: name, Err: err}
+	}
+	if !created {
+		return &fs.PathError{Op: "mkdir", Path: name, Err: fs.ErrExist}
+	}
+
+	return w.chmod("mkdir", name, p, perm)
+}
+
+// MkdirAll creates the named folder and all of its parent folders.
+// The permission bits (less umask 022) are applied to every created folder.
+// If the folder or a parent folder exists as file, syscall.ENOTDIR is reported.
+func (w *WritableFS) MkdirAll(name string, perm fs.FileMode) error {
+	p, err := w.remotePath("mkdir", name)
+	if err != nil {
+		return err
+	}
+
+	// find the nearest existing folder
+	existing := "."
+	for dir := name; dir != "."; dir = path.Dir(dir) {
+		info, err := w.Stat(dir)
+		if isNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return &fs.PathError{Op: "mkdir", Path: name, Err: unwrapPathError(err)}
+		}
+		if !info.IsDir() {
+			return &fs.PathError{Op: "mkdir", Path: dir, Err: syscall.ENOTDIR}
+		}
+		if dir == name {
+			return nil
+		}
+		existing = dir
+		break
+	}
+
+	created, err := w.session.EnsureFolder(p)
+	if err != nil {
+		return &fs.PathError{Op: "mkdir", Path: name, Err: err}
+	}
+	if created == 0 {
+		return nil
+	}
+
+	// collect the created folders, outermost first
+	var dirs []string
+	for dir := name; dir != existing; dir = path.Dir(dir) {
+		dirs = append([]string{dir}, dirs...)
+	}
+
+	for _, dir := range dirs {
+		dirPath, err := w.remotePath("mkdir", dir)
+		if err != nil {
+			return err
+		}
+		if err := w.chmod("mkdir", dir, dirPath, perm); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Remove removes the named file or empty folder.
+// A folder with content is reported as syscall.ENOTEMPTY.
+func (w *WritableFS) Remove(name string) error {
+	info, err := w.Stat(name)
+	if err != nil {
+		return &fs.PathError{Op: "remove", Path: name, Err: unwrapPathError(err)}
+	}
+
+	if info.IsDir() {
+		entries, err := w.ReadDir(name)
+		if err != nil {
+			return &fs.PathError{Op: "remove", Path: name, Err: unwrapPathError(err)}
+		}
+		if len(entries) > 0 {
+			return &fs.PathError{Op: "remove", Path: name, Err: syscall.ENOTEMPTY}
+		}
+	}
+
+	return w.remove("remove", name)
+}
+
+// RemoveAll removes the named file or folder, including its content.
+// A missing file or folder is not reported as error.
+func (w *WritableFS) RemoveAll(name string) error {
+	err := w.remove("removeall", name)
+	if isNotExist(err) {
+		return nil
+	}
+	return err
+}
+
+// remove deletes the named file or folder, including its content.
+func (w *WritableFS) remove(op, name string) error {
+	p, err := w.remotePath(op, name)
+	if err != nil {
+		return err
+	}
+
+	result, err := w.session.Delete(p, false)
+	if err != nil {
+		return &fs.PathError{Op: op, Path: name, Err: err}
+	}
+
+	switch result {
+	case DeleteResult_NotFound:
+		return &fs.PathError{Op: op, Path: name, Err: fs.ErrNotExist}
+	case DeleteResult_PermissionDenied:
+		return &fs.PathError{Op: op, Path: name, Err: fs.ErrPermission}
+	}
+
+	return nil
+}
+
+// Rename renames or moves a file or folder.
+//
+// Moving to another folder and renaming are two separate calls, so the
+// operation is not atomic. If renaming fails after moving, the file or
+// folder is moved back. A failure of moving back is reported as part
+// of the error.
+func (w *WritableFS) Rename(oldname, newname string) error {
+	oldPath, err := w.remotePath("rename", oldname)
+	if err != nil {
+		return err
+	}
+	newPath, err := w.remotePath("rename", newname)
+	if err != nil {
+		return err
+	}
+
+	// move into the new parent folder, first
+	origPath := oldPath
+	if path.Dir(oldPath) != path.Dir(newPath) {
+		if err := w.session.Move(oldPath, path.Dir(newPath)); err != nil {
+			return &fs.PathError{Op: "rename", Path: oldname, Err: err}
+		}
+		oldPath = path.Join(path.Dir(newPath), path.Base(oldPath))
+	}
+
+	if path.Base(oldPath) != path.Base(newPath) {
+		if err := w.session.Rename(oldPath, path.Base(newPath)); err != nil {
+			// roll back the move
+			if path.Dir(oldPath) != path.Dir(origPath) {
+				if rollbackErr := w.session.Move(oldPath, path.Dir(origPath)); rollbackErr != nil {
+					err = fmt.Errorf("%w (moving back from %v failed: %v)", err, oldPath, rollbackErr)
+				}
+			}
+			return &fs.PathError{Op: "rename", Path: oldname, Err: err}
+		}
+	}
+
+	return nil
+}
+
+// Chmod changes the permission bits of the named file or folder.
+// Unlike when creating, the mode is applied unmodified.
+func (w *WritableFS) Chmod(name string, mode fs.FileMode) error {
+	p, err := w.remotePath("chmod", name)
+	if err != nil {
+		return err
+	}
+
+	if err := w.session.SetPrivilege(p, Privilege(mode.Perm()), false); err != nil {
+		return &fs.PathError{Op: "chmod", Path: name, Err: err}
+	}
+
+	return nil
+}
+
+// chmod applies the permission bits of a created file or folder, less umask.
+func (w *WritableFS) chmod(op, name, p string, perm fs.FileMode) error {
+	perm = perm.Perm() &^ umask
+	if perm == 0 {
+		return nil
+	}
+
+	if err := w.session.SetPrivilege(p, Privilege(perm), false); err != nil {
+		return &fs.PathError{Op: op, Path: name, Err: err}
+	}
+
+	return nil
+}
+
+func isNotExist(err error) bool {
+	return err != nil && errors.Is(err, fs.ErrNotExist)
+}
+
+// writableFile keeps the content in memory and uploads it on close.
+type writableFile struct {
+	fs       *WritableFS
+	name     string
+	path     string
+	flag     int
+	perm     fs.FileMode
+	created  bool
+	modified bool
+	closed   bool
+	content  []byte
+	offset   int64
+}
+
+func (f *writableFile) Stat() (fs.FileInfo, error) {
+	return &fileInfo{entry: &FileListEntry{
+		Name:      path.Base(f.path),
+		FullPath:  f.path,
+		Exists:    1,
+		FileSize:  int64(len(f.content)),
+		Privilege: Privilege(f.perm.Perm() &^ umask).String(),
+	}}, nil
+}
+
+func (f *writableFile) Read(b []byte) (int, error) {
+	if f.closed {
+		return 0, fs.ErrClosed
+	}
+	if f.flag&(os.O_WRONLY|os.O_RDWR) == os.O_WRONLY {
+		return 0, &fs.PathError{Op: "read", Path: f.name, Err: fs.ErrPermission}
+	}
+	if f.offset >= int64(len(f.content)) {
+		return 0, io.EOF
+	}
+
+	n := copy(b, f.content[f.offset:])
+	f.offset += int64(n)
+	return n, nil
+}
+
+func (f *writableFile) Write(b []byte) (int, error) {
+	if f.closed {
+		return 0, fs.ErrClosed
+	}
+	if f.flag&(os.O_WRONLY|os.O_RDWR) == 0 {
+		return 0, &fs.PathError{Op: "write", Path: f.name, Err: fs.ErrPermission}
+	}
+	if f.flag&os.O_APPEND != 0 {
+		f.offset = int64(len(f.content))
+	}
+
+	// grow the content, if required
+	end := f.offset + int64(len(b))
+	if end > int64(len(f.content)) {
+		f.content = append(f.content, make([]byte, end-int64(len(f.content)))...)
+	}
+
+	copy(f.content[f.offset:], b)
+	f.offset = end
+	f.modified = true
+	return len(b), nil
+}
+
+func (f *writableFile) Seek(offset int64, whence int) (int64, error) {
+	if f.closed {
+		return 0, fs.ErrClosed
+	}
+
+	switch whence {
+	case io.SeekCurrent:
+		offset += f.offset
+	case io.SeekEnd:
+		offset += int64(len(f.content))
+	case io.SeekStart:
+	default:
+		return 0, &fs.PathError{Op: "seek", Path: f.name, Err: fs.ErrInvalid}
+	}
+	if offset < 0 {
+		return 0, &fs.PathError{Op: "seek", Path: f.name, Err: fs.ErrInvalid}
+	}
+
+	f.offset = offset
+	return offset, nil
+}
+
+// Close uploads the content, if modified.
+func (f *writableFile) Close() error {
+	if f.closed {
+		return fs.ErrClosed
+	}
+	f.closed = true
+
+	if !f.modified {
+		return nil
+	}
+
+	if err := f.fs.session.Upload(f.path, bytes.NewReader(f.content), true); err != nil {
+		return &fs.PathError{Op: "close", Path: f.name, Err: err}
+	}
+
+	if f.created {
+		return f.fs.chmod("close", f.name, f.path, f.perm)
+	}
+
+	return nil
+}
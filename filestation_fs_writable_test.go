@@ -0,0 +1,285 @@
+package filestation
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"strings"
+	"syscall"
+	"testing"
+)
+
+func TestWritableFileBuffer(t *testing.T) {
+	f := &writableFile{name: "test.txt", path: "/Public/test.txt", flag: os.O_RDWR}
+
+	if _, err := f.Write([]byte("hello world")); err != nil {
+		t.Fatalf("Failed to write: %v", err)
+	}
+	if _, err := f.Seek(6, io.SeekStart); err != nil {
+		t.Fatalf("Failed to seek: %v", err)
+	}
+	if _, err := f.Write([]byte("there")); err != nil {
+		t.Fatalf("Failed to write: %v", err)
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		t.Fatalf("Failed to seek: %v", err)
+	}
+
+	content, err := io.ReadAll(f)
+	if err != nil {
+		t.Fatalf("Failed to read: %v", err)
+	}
+	if string(content) != "hello there" {
+		t.Fatalf("Wrong content: %q", content)
+	}
+
+	info, _ := f.Stat()
+	if info.Size() != 11 || info.Name() != "test.txt" {
+		t.Fatalf("Wrong file info: %v %v", info.Name(), info.Size())
+	}
+}
+
+func TestWritableFileSeekInvalid(t *testing.T) {
+	f := &writableFile{name: "test.txt", flag: os.O_RDWR, content: []byte("test")}
+
+	if _, err := f.Seek(0, 3); !errors.Is(err, fs.ErrInvalid) {
+		t.Fatalf("Expected invalid whence to fail: %v", err)
+	}
+	if _, err := f.Seek(-1, io.SeekStart); !errors.Is(err, fs.ErrInvalid) {
+		t.Fatalf("Expected negative offset to fail: %v", err)
+	}
+}
+
+func TestWritableFileReadOnly(t *testing.T) {
+	f := &writableFile{name: "test.txt", flag: os.O_RDONLY, content: []byte("test")}
+
+	if _, err := f.Write([]byte("x")); err == nil {
+		t.Fatal("Expected write to read-only file to fail")
+	}
+}
+
+func createWritableTestFS(t *testing.T) *WritableFS {
+	m := NewMemoryFileStation()
+	if err := m.AddShare("Public", false); err != nil {
+		t.Fatalf("Failed to create share: %v", err)
+	}
+
+	return NewWritableFS(m, "/Public")
+}
+
+func TestWritableFSRemove(t *testing.T) {
+	w := createWritableTestFS(t)
+
+	if err := w.MkdirAll("a/b", 0755); err != nil {
+		t.Fatalf("Failed to create folders: %v", err)
+	}
+
+	if err := w.Remove("a"); !errors.Is(err, syscall.ENOTEMPTY) {
+		t.Fatalf("Expected removing a folder with content to fail: %v", err)
+	}
+	if err := w.Remove("a/b"); err != nil {
+		t.Fatalf("Failed to remove empty folder: %v", err)
+	}
+	if err := w.Remove("a/b"); !errors.Is(err, fs.ErrNotExist) {
+		t.Fatalf("Expected missing folder: %v", err)
+	}
+
+	if err := w.MkdirAll("a/b", 0755); err != nil {
+		t.Fatalf("Failed to create folders: %v", err)
+	}
+	if err := w.RemoveAll("a"); err != nil {
+		t.Fatalf("Failed to remove folder with content: %v", err)
+	}
+}
+
+func TestWritableFSMkdirAllFile(t *testing.T) {
+	w := createWritableTestFS(t)
+
+	f, err := w.Create("file")
+	if err != nil {
+		t.Fatalf("Failed to create file: %v", err)
+	}
+	if err := f.Close(); err != nil {
+		t.Fatalf("Failed to close file: %v", err)
+	}
+
+	if err := w.MkdirAll("file", 0755); !errors.Is(err, syscall.ENOTDIR) {
+		t.Fatalf("Expected existing file to fail: %v", err)
+	}
+	if err := w.MkdirAll("file/sub", 0755); !errors.Is(err, syscall.ENOTDIR) {
+		t.Fatalf("Expected file as parent to fail: %v", err)
+	}
+}
+
+func TestWritableFSRenameRollback(t *testing.T) {
+	w := createWritableTestFS(t)
+
+	for _, name := range []string{"a/x", "b/y"} {
+		if err := w.MkdirAll(name, 0755); err != nil {
+			t.Fatalf("Failed to create folder: %v", err)
+		}
+	}
+
+	// the move succeeds, but the new name exists
+	if err := w.Rename("a/x", "b/y"); err == nil {
+		t.Fatal("Expected rename to fail")
+	}
+
+	if _, err := w.Stat("a/x"); err != nil {
+		t.Fatalf("Expected move to be rolled back: %v", err)
+	}
+	if _, err := w.Stat("b/x"); !errors.Is(err, fs.ErrNotExist) {
+		t.Fatalf("Expected moved folder to be gone: %v", err)
+	}
+}
+
+func TestWritableFSMkdir(t *testing.T) {
+	w := createWritableTestFS(t)
+
+	if err := w.Mkdir("a/b", 0755); !errors.Is(err, fs.ErrNotExist) {
+		t.Fatalf("Expected missing parent to fail: %v", err)
+	}
+	if err := w.Mkdir("a", 0777); err != nil {
+		t.Fatalf("Failed to create folder: %v", err)
+	}
+	if err := w.Mkdir("a", 0777); !errors.Is(err, fs.ErrExist) {
+		t.Fatalf("Expected existing folder to fail: %v", err)
+	}
+
+	info, err := w.Stat("a")
+	if err != nil {
+		t.Fatalf("Failed to stat folder: %v", err)
+	}
+	if info.Mode() != fs.ModeDir|0755 {
+		t.Fatalf("Expected mode to be masked by umask: %v", info.Mode())
+	}
+}
+
+func TestWritableFSMkdirAllMode(t *testing.T) {
+	w := createWritableTestFS(t)
+
+	if err := w.Mkdir("a", 0700); err != nil {
+		t.Fatalf("Failed to create folder: %v", err)
+	}
+	if err := w.MkdirAll("a/b/c", 0750); err != nil {
+		t.Fatalf("Failed to create folders: %v", err)
+	}
+
+	for name, mode := range map[string]fs.FileMode{"a": 0700, "a/b": 0750, "a/b/c": 0750} {
+		info, err := w.Stat(name)
+		if err != nil {
+			t.Fatalf("Failed to stat %v: %v", name, err)
+		}
+		if info.Mode().Perm() != mode {
+			t.Fatalf("Wrong mode of %v: %v", name, info.Mode())
+		}
+	}
+}
+
+func TestWritableFSCreateMode(t *testing.T) {
+	w := createWritableTestFS(t)
+
+	f, err := w.Create("file")
+	if err != nil {
+		t.Fatalf("Failed to create file: %v", err)
+	}
+	if err := f.Close(); err != nil {
+		t.Fatalf("Failed to close file: %v", err)
+	}
+
+	info, err := w.Stat("file")
+	if err != nil {
+		t.Fatalf("Failed to stat file: %v", err)
+	}
+	if info.Mode() != 0644 {
+		t.Fatalf("Expected mode to be masked by umask: %v", info.Mode())
+	}
+}
+
+func TestWritableFSChmod(t *testing.T) {
+	w := createWritableTestFS(t)
+
+	if err := w.Mkdir("a", 0755); err != nil {
+		t.Fatalf("Failed to create folder: %v", err)
+	}
+
+	// the mode is not masked
+	if err := w.Chmod("a", 0777); err != nil {
+		t.Fatalf("Failed to change mode: %v", err)
+	}
+
+	info, err := w.Stat("a")
+	if err != nil {
+		t.Fatalf("Failed to stat folder: %v", err)
+	}
+	if info.Mode().Perm() != 0777 {
+		t.Fatalf("Wrong mode: %v", info.Mode())
+	}
+
+	if err := w.Chmod("missing", 0755); !errors.Is(err, fs.ErrNotExist) {
+		t.Fatalf("Expected missing folder to fail: %v", err)
+	}
+}
+
+func TestWritableFSDirFile(t *testing.T) {
+	w := createWritableTestFS(t)
+
+	if err := w.Mkdir("a", 0755); err != nil {
+		t.Fatalf("Failed to create folder: %v", err)
+	}
+
+	if _, err := w.OpenFile("a", os.O_RDWR, 0); !errors.Is(err, fs.ErrInvalid) {
+		t.Fatalf("Expected opening a folder for writing to fail: %v", err)
+	}
+
+	f, err := w.OpenFile("a", os.O_RDONLY, 0)
+	if err != nil {
+		t.Fatalf("Failed to open folder: %v", err)
+	}
+	defer f.Close()
+
+	if _, err := f.Read(make([]byte, 1)); !errors.Is(err, fs.ErrInvalid) {
+		t.Fatalf("Expected read to fail: %v", err)
+	}
+	if _, err := f.Write([]byte("x")); !errors.Is(err, fs.ErrInvalid) {
+		t.Fatalf("Expected write to fail: %v", err)
+	}
+	if _, err := f.Seek(0, io.SeekStart); !errors.Is(err, fs.ErrInvalid) {
+		t.Fatalf("Expected seek to fail: %v", err)
+	}
+}
+
+// failingMoveFileStation fails all moves after the first one.
+type failingMoveFileStation struct {
+	FileStation
+	moves int
+}
+
+func (f *failingMoveFileStation) Move(path, destFolder string) error {
+	f.moves++
+	if f.moves > 1 {
+		return errors.New("move failed")
+	}
+	return f.FileStation.Move(path, destFolder)
+}
+
+func TestWritableFSRenameRollbackFailed(t *testing.T) {
+	w := createWritableTestFS(t)
+
+	for _, name := range []string{"a/x", "b/y"} {
+		if err := w.MkdirAll(name, 0755); err != nil {
+			t.Fatalf("Failed to create folder: %v", err)
+		}
+	}
+
+	w.session = &failingMoveFileStation{FileStation: w.session}
+
+	err := w.Rename("a/x", "b/y")
+	if err == nil {
+		t.Fatal("Expected rename to fail")
+	}
+	if !strings.Contains(err.Error(), "moving back") {
+		t.Fatalf("Expected failed rollback to be reported: %v", err)
+	}
+}
@@ -0,0 +1,37 @@
+package filestation
+
+import (
+	"context"
+	"io"
+)
+
+// FileStation is the set of file operations of a File Station session.
+// It is implemented by FileStationSession and MemoryFileStation, so code
+// depending on it can be unit-tested without a QNAP system.
+type FileStation interface {
+	GetShareList() ([]FolderListEntry, error)
+	GetFolderTree(ctx context.Context, path string, depth int) (*FolderTreeNode, error)
+	GetFileList(path string) ([]FileListEntry, error)
+	GetFileListWithOptions(path string, opts ListOptions) ([]FileListEntry, error)
+	ListIter(ctx context.Context, path string, opts ListOptions) *ListIterator
+	GetFileStat(path string) (*FileListEntry, error)
+	Walk(ctx context.Context, root string, fn WalkFunc, opts WalkOptions) error
+
+	SetPrivilege(path string, privilege Privilege, recursive bool) error
+	CreateFolder(path string) (bool, error)
+	EnsureFolder(path string) (int, error)
+	DeleteFile(path string) (bool, error)
+	DeleteFileNoRecycleBin(path string) (bool, error)
+	Delete(path string, noRecycleBin bool) (DeleteResult, error)
+	Download(path string) (io.ReadCloser, error)
+	Upload(path string, content io.Reader, overwrite bool) error
+	Rename(path, newName string) error
+	Move(path, destFolder string) error
+
+	Close() error
+}
+
+var (
+	_ FileStation = (*FileStationSession)(nil)
+	_ FileStation = (*MemoryFileStation)(nil)
+)
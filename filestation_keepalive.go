@@ -0,0 +1,46 @@
+package filestation
+
+import "time"
+
+// startKeepAlive issues a cheap authenticated call in the given
+// interval, so the session does not expire while being idle.
+func (s *FileStationSession) startKeepAlive(interval time.Duration) {
+	stop := make(chan struct{})
+	done := make(chan struct{})
+
+	s.keepAliveStop = stop
+	s.keepAliveDone = done
+
+	go func() {
+		defer close(done)
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+			}
+
+			_, err := s.GetShareList()
+			if err != nil && s.options.KeepAliveErrorHandler != nil {
+				s.options.KeepAliveErrorHandler(err)
+			}
+		}
+	}()
+}
+
+// stopKeepAlive stops the keepalive and waits for any pending call.
+// It is safe to be called concurrently and multiple times.
+func (s *FileStationSession) stopKeepAlive() {
+	s.keepAliveOnce.Do(func() {
+		if s.keepAliveStop == nil {
+			return
+		}
+
+		close(s.keepAliveStop)
+		<-s.keepAliveDone
+	})
+}
@@ -0,0 +1,101 @@
+package filestation
+
+import (
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestKeepAlive(t *testing.T) {
+	var calls int32
+
+	s, _ := createStubSession(t, &ConfigOptions{
+		APICallTimeout:    5 * time.Second,
+		KeepAliveInterval: 10 * time.Millisecond,
+	}, func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.Write([]byte(`[]`))
+	})
+
+	time.Sleep(100 * time.Millisecond)
+
+	if err := s.Close(); err != nil {
+		t.Fatalf("Failed to close session: %v", err)
+	}
+
+	n := atomic.LoadInt32(&calls)
+	if n == 0 {
+		t.Fatal("Expected keepalive calls")
+	}
+
+	time.Sleep(50 * time.Millisecond)
+
+	if atomic.LoadInt32(&calls) != n {
+		t.Fatal("Expected keepalive to be stopped")
+	}
+}
+
+func TestKeepAlive_ConcurrentClose(t *testing.T) {
+	s, _ := createStubSession(t, &ConfigOptions{
+		APICallTimeout:    5 * time.Second,
+		KeepAliveInterval: time.Millisecond,
+	}, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`[]`))
+	})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s.Close()
+		}()
+	}
+	wg.Wait()
+
+	if err := s.Close(); err != nil {
+		t.Fatalf("Expected repeated close to succeed: %v", err)
+	}
+}
+
+func TestKeepAlive_SessionExpired(t *testing.T) {
+	var calls int32
+
+	s, stub := createStubSession(t, &ConfigOptions{
+		APICallTimeout:    5 * time.Second,
+		KeepAliveInterval: time.Millisecond,
+		RememberPassword:  true,
+	}, func(w http.ResponseWriter, r *http.Request) {
+		// the session expires every few calls
+		if atomic.AddInt32(&calls, 1)%5 == 0 {
+			w.Write([]byte(`{"status":3}`))
+			return
+		}
+		w.Write([]byte(`[]`))
+	})
+
+	// the session expires while being used concurrently by the keepalive
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			for j := 0; j < 20; j++ {
+				s.GetShareList()
+				s.ServerInfo()
+			}
+		}()
+	}
+	wg.Wait()
+
+	if err := s.Close(); err != nil {
+		t.Fatalf("Failed to close session: %v", err)
+	}
+
+	if atomic.LoadInt32(&stub.logins) < 2 {
+		t.Fatal("Expected session to be renewed")
+	}
+}
@@ -0,0 +1,217 @@
+package filestation
+
+import (
+	"context"
+	"strings"
+)
+
+// defaultPageSize is the number of entries retrieved per request.
+const defaultPageSize = 1000
+
+// SortField is the field used for sorting folder listings.
+type SortField int
+
+const (
+	SortField_Default SortField = iota // order of the server, no sort parameter is sent
+	SortField_Name                     // file name
+	SortField_Size                     // file size
+	SortField_ModTime                  // modification time
+	SortField_Type                     // file type
+)
+
+// param returns the value of the sort parameter of get_list,
+// or an empty string if the parameter is not sent.
+func (f SortField) param() string {
+	switch f {
+	case SortField_Name:
+		return "filename"
+	case SortField_Size:
+		return "filesize"
+	case SortField_ModTime:
+		return "mt"
+	case SortField_Type:
+		return "filetype"
+	}
+
+	return ""
+}
+
+// systemFolderNames lists the folders maintained by the QNAP system.
+var systemFolderNames = map[string]bool{
+	"@Recycle":           true,
+	"@Recently-Snapshot": true,
+	".streams":           true,
+}
+
+// IsSystemFolder checks if the name belongs to a folder maintained
+// by the QNAP system, e.g. "@Recycle" or ".@__thumb".
+func IsSystemFolder(name string) bool {
+	return systemFolderNames[name] || strings.HasPrefix(name, ".@")
+}
+
+// ListOptions controls the listing of a folder.
+type ListOptions struct {
+	// PageSize is the number of entries retrieved per request.
+	// Zero uses the default of 1000 entries.
+	PageSize int
+
+	// SortBy is the field the entries are sorted by (server-side).
+	// By default, the order of the server is kept.
+	SortBy SortField
+
+	// Descending reverses the sort order. Without SortBy,
+	// the entries are sorted by name.
+	Descending bool
+
+	// FoldersOnly skips all files, FilesOnly skips all folders.
+	FoldersOnly bool
+	FilesOnly   bool
+
+	// HideSystemFolders skips the folders maintained by the
+	// QNAP system, see IsSystemFolder().
+	HideSystemFolders bool
+
+	// NameFilter only returns entries, whose name contains the
+	// given text (case-insensitive). It is also passed to the
+	// server as filename parameter, so less entries need to be
+	// transferred. As the parameter has not been verified with
+	// every firmware, the entries are always filtered client-side.
+	NameFilter string
+}
+
+// match checks if the entry passes the client-side filters.
+func (o *ListOptions) match(e *FileListEntry) bool {
+	if o.FoldersOnly && !e.IsDir() {
+		return false
+	}
+	if o.FilesOnly && e.IsDir() {
+		return false
+	}
+	if o.HideSystemFolders && e.IsDir() && IsSystemFolder(e.Name) {
+		return false
+	}
+	if o.NameFilter != "" && !strings.Contains(strings.ToLower(e.Name), strings.ToLower(o.NameFilter)) {
+		return false
+	}
+
+	return true
+}
+
+// PageFetcher retrieves up to limit entries of a folder, starting at
+// the given index. Returning less than limit entries ends the iteration.
+type PageFetcher func(ctx context.Context, start, limit int) ([]FileListEntry, error)
+
+// ListIterator iterates over the entries of a folder.
+// The entries are retrieved page by page, while iterating:
+//
+//	it := session.ListIter(ctx, "/Public", ListOptions{})
+//	for it.Next() {
+//		fmt.Println(it.Entry().Name)
+//	}
+//	if err := it.Err(); err != nil {
+//		...
+//	}
+type ListIterator struct {
+	ctx      context.Context
+	fetch    PageFetcher
+	opts     ListOptions
+	pageSize int
+
+	page    []FileListEntry
+	index   int
+	fetched int
+	last    bool
+	err     error
+}
+
+// ListIter creates an iterator over the files and folders of a folder.
+func (s *FileStationSession) ListIter(ctx context.Context, path string, opts ListOptions) *ListIterator {
+	path, err := checkPath("GetFileList", path)
+
+	it := NewListIterator(ctx, opts, func(ctx context.Context, start, limit int) ([]FileListEntry, error) {
+		return s.getFileListPage(ctx, path, start, limit, &opts)
+	})
+	it.err = err
+
+	return it
+}
+
+// NewListIterator creates an iterator, which retrieves the pages by fetch
+// and applies the client-side filters of opts. It allows implementations
+// of the FileStation interface, e.g. mocks, to provide ListIter().
+func NewListIterator(ctx context.Context, opts ListOptions, fetch PageFetcher) *ListIterator {
+	pageSize := opts.PageSize
+	if pageSize <= 0 {
+		pageSize = defaultPageSize
+	}
+
+	return &ListIterator{
+		ctx:      ctx,
+		fetch:    fetch,
+		opts:     opts,
+		pageSize: pageSize,
+		index:    -1,
+	}
+}
+
+// Next advances to the next entry. It returns false when there are
+// no more entries, the iterator has been closed or an error occurred.
+func (it *ListIterator) Next() bool {
+	for it.nextEntry() {
+		if it.opts.match(&it.page[it.index]) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (it *ListIterator) nextEntry() bool {
+	if it.err != nil {
+		return false
+	}
+
+	it.index++
+
+	for it.index >= len(it.page) {
+		if it.last {
+			return false
+		}
+		if err := it.ctx.Err(); err != nil {
+			it.err = err
+			return false
+		}
+
+		page, err := it.fetch(it.ctx, it.fetched, it.pageSize)
+		if err != nil {
+			it.err = err
+			return false
+		}
+
+		it.page = page
+		it.index = 0
+		it.fetched += len(page)
+		it.last = len(page) < it.pageSize
+	}
+
+	return true
+}
+
+// Entry returns the current entry.
+func (it *ListIterator) Entry() *FileListEntry {
+	if it.index < 0 || it.index >= len(it.page) {
+		return nil
+	}
+	return &it.page[it.index]
+}
+
+// Err returns the error, which stopped the iteration, if any.
+func (it *ListIterator) Err() error {
+	return it.err
+}
+
+// Close stops the iteration early. No further pages are retrieved.
+func (it *ListIterator) Close() {
+	it.page = nil
+	it.last = true
+}
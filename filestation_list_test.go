@@ -0,0 +1,132 @@
+package filestation
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"testing"
+)
+
+func testFetcher(total int, fetches *int) PageFetcher {
+	return func(ctx context.Context, start, limit int) ([]FileListEntry, error) {
+		*fetches++
+
+		var ret []FileListEntry
+		for i := start; i < total && i < start+limit; i++ {
+			ret = append(ret, FileListEntry{Name: "file" + strconv.Itoa(i)})
+		}
+		return ret, nil
+	}
+}
+
+func TestListIterator(t *testing.T) {
+	fetches := 0
+	it := NewListIterator(context.Background(), ListOptions{PageSize: 10}, testFetcher(25, &fetches))
+
+	count := 0
+	for it.Next() {
+		if it.Entry().Name != "file"+strconv.Itoa(count) {
+			t.Fatalf("Wrong entry: %v", it.Entry().Name)
+		}
+		count++
+	}
+	if err := it.Err(); err != nil {
+		t.Fatalf("Failed to iterate: %v", err)
+	}
+	if count != 25 || fetches != 3 {
+		t.Fatalf("Wrong number of entries or pages: %v %v", count, fetches)
+	}
+}
+
+func TestListIteratorClose(t *testing.T) {
+	fetches := 0
+	it := NewListIterator(context.Background(), ListOptions{PageSize: 10}, testFetcher(100, &fetches))
+
+	for i := 0; i < 5 && it.Next(); i++ {
+	}
+	it.Close()
+
+	if it.Next() {
+		t.Fatal("Expected iteration to stop")
+	}
+	if fetches != 1 {
+		t.Fatalf("Expected a single page to be fetched, got %v", fetches)
+	}
+}
+
+func TestListIteratorError(t *testing.T) {
+	failure := errors.New("failure")
+
+	it := NewListIterator(context.Background(), ListOptions{}, func(ctx context.Context, start, limit int) ([]FileListEntry, error) {
+		return nil, failure
+	})
+
+	if it.Next() {
+		t.Fatal("Expected iteration to fail")
+	}
+	if it.Err() != failure {
+		t.Fatalf("Wrong error: %v", it.Err())
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	fetches := 0
+	it = NewListIterator(ctx, ListOptions{}, testFetcher(10, &fetches))
+	if it.Next() || !errors.Is(it.Err(), context.Canceled) {
+		t.Fatalf("Expected cancelled iteration, got %v", it.Err())
+	}
+}
+
+func TestListIteratorFilter(t *testing.T) {
+	fetch := func(ctx context.Context, start, limit int) ([]FileListEntry, error) {
+		var ret []FileListEntry
+		for i := start; i < 30 && i < start+limit; i++ {
+			e := FileListEntry{Name: "file" + strconv.Itoa(i)}
+			if i%3 == 0 {
+				e.Name = "dir" + strconv.Itoa(i)
+				e.IsFolder = 1
+			}
+			ret = append(ret, e)
+		}
+		return ret, nil
+	}
+
+	count := 0
+	it := NewListIterator(context.Background(), ListOptions{PageSize: 4, FoldersOnly: true}, fetch)
+	for it.Next() {
+		if !it.Entry().IsDir() {
+			t.Fatalf("Expected only folders: %v", it.Entry().Name)
+		}
+		count++
+	}
+	if count != 10 {
+		t.Fatalf("Expected 10 folders, got %v", count)
+	}
+}
+
+func TestListOptionsMatch(t *testing.T) {
+	opts := &ListOptions{HideSystemFolders: true, NameFilter: "Report"}
+
+	tests := []struct {
+		entry    FileListEntry
+		expected bool
+	}{
+		{FileListEntry{Name: "annual-report.pdf"}, true},
+		{FileListEntry{Name: "invoice.pdf"}, false},
+		{FileListEntry{Name: "@Recycle", IsFolder: 1}, false},
+		{FileListEntry{Name: ".@__thumb", IsFolder: 1}, false},
+		{FileListEntry{Name: "reports", IsFolder: 1}, true},
+	}
+
+	for _, tt := range tests {
+		if opts.match(&tt.entry) != tt.expected {
+			t.Errorf("match(%v) != %v", tt.entry.Name, tt.expected)
+		}
+	}
+
+	opts = &ListOptions{FilesOnly: true}
+	if opts.match(&FileListEntry{Name: "folder", IsFolder: 1}) {
+		t.Fatal("Expected folder to be skipped")
+	}
+}
@@ -0,0 +1,686 @@
+package filestation
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// recycleFolderName is the folder of a share, which holds the deleted files.
+const recycleFolderName = "@Recycle"
+
+// MemoryFileStation is an in-memory implementation of FileStation, intended
+// for unit tests. It keeps a virtual folder tree with owners, privileges and
+// recycle bins, and reports errors the same way as a QNAP system.
+//
+// Shares must be created by AddShare(), before files and folders can be added.
+type MemoryFileStation struct {
+	// User and Group own the new files and folders. The privileges are
+	// checked against them, unless the user is "admin". They must not be
+	// changed while the file station is in use by other goroutines.
+	User  string
+	Group string
+
+	mu     sync.Mutex
+	shares map[string]*memoryNode
+}
+
+type memoryNode struct {
+	name       string
+	folder     bool
+	content    []byte
+	owner      string
+	group      string
+	privilege  Privilege
+	modTime    time.Time
+	recycleBin bool // shares only
+	children   map[string]*memoryNode
+}
+
+// NewMemoryFileStation creates an empty in-memory file station,
+// which is used by the "admin" user.
+func NewMemoryFileStation() *MemoryFileStation {
+	return &MemoryFileStation{
+		User:   "admin",
+		Group:  "administrators",
+		shares: make(map[string]*memoryNode),
+	}
+}
+
+// AddShare creates a new share. If recycleBin is set, deleted files and
+// folders are moved to the "@Recycle" folder of the share.
+func (m *MemoryFileStation) AddShare(name string, recycleBin bool) error {
+	if err := ValidateName(name); err != nil {
+		return newError("AddShare", "/"+name, nil, err)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.shares[name]; ok {
+		return newError("AddShare", "/"+name, nil, WFM2_FILE_EXIST)
+	}
+
+	share := newMemoryNode(name, true, "admin", "administrators")
+	share.recycleBin = recycleBin
+	m.shares[name] = share
+
+	return nil
+}
+
+// SetOwner changes the owner and group of a file or folder.
+func (m *MemoryFileStation) SetOwner(path, owner, group string) error {
+	path, err := checkPath("SetOwner", path)
+	if err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	n := m.lookup(path)
+	if n == nil {
+		return newError("SetOwner", path, nil, WFM2_FILE_NO_EXIST)
+	}
+
+	n.owner = owner
+	n.group = group
+
+	return nil
+}
+
+// Close does nothing, as there is no session to invalidate.
+func (m *MemoryFileStation) Close() error {
+	return nil
+}
+
+// GetShareList retrieves the list of shares.
+func (m *MemoryFileStation) GetShareList() ([]FolderListEntry, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	ret := make([]FolderListEntry, 0, len(m.shares))
+	for _, share := range m.shares {
+		e := share.folderEntry("/" + share.name)
+		e.RecycleBin = boolToIntStr(share.recycleBin)
+		ret = append(ret, e)
+	}
+
+	sort.Slice(ret, func(i, j int) bool { return ret[i].Path < ret[j].Path })
+
+	return ret, nil
+}
+
+// GetFolderTree retrieves the folders below the path up to the given depth.
+func (m *MemoryFileStation) GetFolderTree(ctx context.Context, path string, depth int) (*FolderTreeNode, error) {
+	return getFolderTree(ctx, path, depth, func(ctx context.Context, path, node string) ([]FolderListEntry, error) {
+		if node == shareRootNode {
+			return m.GetShareList()
+		}
+
+		entries, err := m.list("GetFolderTree", path, &ListOptions{})
+		if err != nil {
+			return nil, err
+		}
+
+		m.mu.Lock()
+		defer m.mu.Unlock()
+
+		ret := make([]FolderListEntry, 0)
+		for _, e := range entries {
+			if n := m.lookup(e.FullPath); n != nil && n.folder {
+				ret = append(ret, n.folderEntry(e.FullPath))
+			}
+		}
+
+		return ret, nil
+	})
+}
+
+// GetFileList retrieves the list of files and folders of a share.
+func (m *MemoryFileStation) GetFileList(path string) ([]FileListEntry, error) {
+	return listAll(m.ListIter(context.Background(), path, ListOptions{}))
+}
+
+// GetFileListWithOptions retrieves the list of files and folders of a share,
+// sorted and filtered as specified by the options.
+func (m *MemoryFileStation) GetFileListWithOptions(path string, opts ListOptions) ([]FileListEntry, error) {
+	return listAll(m.ListIter(context.Background(), path, opts))
+}
+
+// ListIter creates an iterator over the files and folders of a folder.
+func (m *MemoryFileStation) ListIter(ctx context.Context, path string, opts ListOptions) *ListIterator {
+	path, err := checkPath("GetFileList", path)
+
+	it := NewListIterator(ctx, opts, func(ctx context.Context, start, limit int) ([]FileListEntry, error) {
+		entries, err := m.list("GetFileList", path, &opts)
+		if err != nil {
+			return nil, err
+		}
+
+		if start > len(entries) {
+			start = len(entries)
+		}
+		end := start + limit
+		if end > len(entries) {
+			end = len(entries)
+		}
+
+		return entries[start:end], nil
+	})
+	it.err = err
+
+	return it
+}
+
+// Walk traverses the folder tree below root and calls fn for every
+// file and folder, see FileStationSession.Walk().
+func (m *MemoryFileStation) Walk(ctx context.Context, root string, fn WalkFunc, opts WalkOptions) error {
+	root, err := checkPath("Walk", root)
+	if err != nil {
+		return err
+	}
+
+	return walk(ctx, root, fn, opts, func(ctx context.Context, path string) ([]FileListEntry, error) {
+		return m.list("GetFileList", path, &ListOptions{})
+	})
+}
+
+// GetFileStat checks if a file or folder exists.
+func (m *MemoryFileStation) GetFileStat(path string) (*FileListEntry, error) {
+	path, err := checkPath("GetFileStat", path)
+	if err != nil {
+		return nil, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	n := m.lookup(path)
+	if n == nil {
+		return nil, nil
+	}
+
+	entry := n.entry(path)
+	return &entry, nil
+}
+
+// SetPrivilege changes file-system level permissions
+// of a file or folder (chmod). Only the owner is permitted to.
+func (m *MemoryFileStation) SetPrivilege(path string, privilege Privilege, recursive bool) error {
+	path, err := checkPath("SetPrivilege", path)
+	if err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	n := m.lookup(path)
+	if n == nil {
+		return newError("SetPrivilege", path, nil, WFM2_FILE_NO_EXIST)
+	}
+	if m.User != "admin" && n.owner != m.User {
+		return newError("SetPrivilege", path, nil, WFM2_PERMISSION_DENY)
+	}
+
+	n.setPrivilege(privilege&0777, recursive)
+
+	return nil
+}
+
+// CreateFolder creates a new folder.
+// The base directory must exists.
+func (m *MemoryFileStation) CreateFolder(path string) (bool, error) {
+	path, err := checkPath("CreateFolder", path)
+	if err != nil {
+		return false, err
+	}
+
+	dir, name := splitPath(path)
+
+	if err := ValidateName(name); err != nil {
+		return false, newError("CreateFolder", path, nil, err)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	parent, status := m.folderForWrite(dir)
+	if status != WFM2_SUCCESS {
+		return false, newError("CreateFolder", path, nil, status)
+	}
+	if _, ok := parent.children[name]; ok { // folder already exists
+		return false, nil
+	}
+
+	parent.children[name] = newMemoryNode(name, true, m.User, m.Group)
+
+	return true, nil
+}
+
+// EnsureFolder creates a new folder and its parent directories.
+func (m *MemoryFileStation) EnsureFolder(path string) (int, error) {
+	return ensureFolder(m, path)
+}
+
+// DeleteFile deletes a file or folder.
+func (m *MemoryFileStation) DeleteFile(path string) (bool, error) {
+	return m.deleteFileInternal(path, false)
+}
+
+// DeleteFileNoRecycleBin deletes a file or folder without moving them to the recycling bin.
+func (m *MemoryFileStation) DeleteFileNoRecycleBin(path string) (bool, error) {
+	return m.deleteFileInternal(path, true)
+}
+
+func (m *MemoryFileStation) deleteFileInternal(path string, force bool) (bool, error) {
+	path, err := checkPath("DeleteFile", path)
+	if err != nil {
+		return false, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	_, status := m.remove(path, force)
+
+	switch status {
+	case WFM2_SUCCESS: // success
+		return true, nil
+	case WFM2_FAIL, WFM2_PERMISSION_DENY: // file not found
+		return false, nil
+	}
+
+	return false, newError("DeleteFile", path, nil, status)
+}
+
+// Delete deletes a file or folder and reports the outcome.
+func (m *MemoryFileStation) Delete(path string, noRecycleBin bool) (DeleteResult, error) {
+	path, err := checkPath("Delete", path)
+	if err != nil {
+		return 0, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	result, status := m.remove(path, noRecycleBin)
+
+	switch status {
+	case WFM2_SUCCESS: // success
+		return result, nil
+	case WFM2_FAIL, WFM2_FILE_NO_EXIST:
+		return DeleteResult_NotFound, nil
+	case WFM2_PERMISSION_DENY:
+		return DeleteResult_PermissionDenied, nil
+	}
+
+	return 0, newError("Delete", path, nil, status)
+}
+
+// Download opens a file for reading its content.
+func (m *MemoryFileStation) Download(path string) (io.ReadCloser, error) {
+	path, err := checkPath("Download", path)
+	if err != nil {
+		return nil, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	n := m.lookup(path)
+	switch {
+	case n == nil:
+		return nil, newError("Download", path, nil, WFM2_FILE_NO_EXIST)
+	case n.folder:
+		return nil, newError("Download", path, nil, WFM2_FAIL)
+	case !m.allowed(n, 04):
+		return nil, newError("Download", path, nil, WFM2_PERMISSION_DENY)
+	}
+
+	return io.NopCloser(bytes.NewReader(append([]byte(nil), n.content...))), nil
+}
+
+// Upload creates or replaces a file with the content of the reader.
+// The parent folder must exist.
+func (m *MemoryFileStation) Upload(path string, content io.Reader, overwrite bool) error {
+	path, err := checkPath("Upload", path)
+	if err != nil {
+		return err
+	}
+
+	dir, name := splitPath(path)
+
+	if err := ValidateName(name); err != nil {
+		return newError("Upload", path, nil, err)
+	}
+
+	data, err := io.ReadAll(content)
+	if err != nil {
+		return newError("Upload", path, nil, err)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	parent, status := m.folderForWrite(dir)
+	if status != WFM2_SUCCESS {
+		return newError("Upload", path, nil, status)
+	}
+
+	if existing, ok := parent.children[name]; ok {
+		if existing.folder || !overwrite {
+			return newError("Upload", path, nil, WFM2_FILE_EXIST)
+		}
+
+		existing.content = data
+		existing.modTime = time.Now()
+		return nil
+	}
+
+	file := newMemoryNode(name, false, m.User, m.Group)
+	file.content = data
+	parent.children[name] = file
+
+	return nil
+}
+
+// Rename changes the name of a file or folder.
+// The file or folder stays in its parent folder.
+func (m *MemoryFileStation) Rename(path, newName string) error {
+	path, err := checkPath("Rename", path)
+	if err != nil {
+		return err
+	}
+
+	dir, name := splitPath(path)
+
+	if err := ValidateName(newName); err != nil {
+		return newError("Rename", path, nil, err)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	n := m.lookup(path)
+	if n == nil {
+		return newError("Rename", path, nil, WFM2_FILE_NO_EXIST)
+	}
+	if dir == "/" { // shares cannot be renamed
+		return newError("Rename", path, nil, WFM2_PERMISSION_DENY)
+	}
+
+	parent, status := m.folderForWrite(dir)
+	if status != WFM2_SUCCESS {
+		return newError("Rename", path, nil, status)
+	}
+	if newName == name {
+		return nil
+	}
+	if _, ok := parent.children[newName]; ok {
+		return newError("Rename", path, nil, WFM2_FILE_EXIST)
+	}
+
+	delete(parent.children, name)
+	n.name = newName
+	parent.children[newName] = n
+
+	return nil
+}
+
+// Move moves a file or folder into another folder.
+// Existing files are not overwritten.
+func (m *MemoryFileStation) Move(path, destFolder string) error {
+	path, err := checkPath("Move", path)
+	if err != nil {
+		return err
+	}
+	destFolder, err = checkPath("Move", destFolder)
+	if err != nil {
+		return err
+	}
+
+	dir, name := splitPath(path)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	n := m.lookup(path)
+	if n == nil {
+		return newError("Move", path, nil, WFM2_FILE_NO_EXIST)
+	}
+	if dir == "/" { // shares cannot be moved
+		return newError("Move", path, nil, WFM2_PERMISSION_DENY)
+	}
+
+	src, status := m.folderForWrite(dir)
+	if status != WFM2_SUCCESS {
+		return newError("Move", path, nil, status)
+	}
+
+	dest := m.lookup(destFolder)
+	switch {
+	case dest == nil || !dest.folder:
+		return newError("Move", path, nil, WFM2_DES_FILE_NO_EXIST)
+	case !m.allowed(dest, 02):
+		return newError("Move", path, nil, WFM2_DES_PERMISSION_DENY)
+	case destFolder == path || strings.HasPrefix(destFolder, path+"/"): // into itself
+		return newError("Move", path, nil, WFM2_PARAMETER_ERROR)
+	}
+	if _, ok := dest.children[name]; ok {
+		return newError("Move", path, nil, WFM2_FILE_EXIST)
+	}
+
+	delete(src.children, name)
+	dest.children[name] = n
+
+	return nil
+}
+
+// list retrieves the sorted entries of a folder.
+func (m *MemoryFileStation) list(op, path string, opts *ListOptions) ([]FileListEntry, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	n := m.lookup(path)
+	if n == nil || !n.folder {
+		return nil, newError(op, path, nil, WFM2_FILE_NO_EXIST)
+	}
+	if !m.allowed(n, 04) {
+		return nil, newError(op, path, nil, WFM2_PERMISSION_DENY)
+	}
+
+	ret := make([]FileListEntry, 0, len(n.children))
+	for _, c := range n.children {
+		ret = append(ret, c.entry(joinPath(path, c.name)))
+	}
+
+	sortEntries(ret, opts)
+
+	return ret, nil
+}
+
+// remove deletes a file or folder, or moves it to the recycle bin of the share.
+// Files within the recycle bin are always deleted permanently.
+func (m *MemoryFileStation) remove(path string, force bool) (DeleteResult, FileStationStatus) {
+	dir, name := splitPath(path)
+
+	if m.lookup(path) == nil {
+		if parent := m.lookup(dir); parent != nil && parent.folder {
+			return DeleteResult_NotFound, WFM2_FAIL // missing files are reported as failure
+		}
+		return DeleteResult_NotFound, WFM2_FILE_NO_EXIST
+	}
+	if dir == "/" { // shares cannot be deleted
+		return 0, WFM2_PERMISSION_DENY
+	}
+
+	parent, status := m.folderForWrite(dir)
+	if status != WFM2_SUCCESS {
+		return 0, status
+	}
+
+	n := parent.children[name]
+	delete(parent.children, name)
+
+	shareName, _ := ShareOf(path)
+	recycle := joinPath(shareName, recycleFolderName)
+
+	if force || !m.shares[shareName[1:]].recycleBin || path == recycle || strings.HasPrefix(path, recycle+"/") {
+		return DeleteResult_Deleted, WFM2_SUCCESS
+	}
+
+	// keep the original folder structure within the recycle bin
+	folder := m.shares[shareName[1:]]
+	target := recycleFolderName + strings.TrimPrefix(dir, shareName)
+
+	for _, part := range strings.Split(target, "/") {
+		child, ok := folder.children[part]
+		if !ok || !child.folder {
+			child = newMemoryNode(part, true, m.User, m.Group)
+			folder.children[part] = child
+		}
+		folder = child
+	}
+
+	folder.children[name] = n
+
+	return DeleteResult_MovedToRecycleBin, WFM2_SUCCESS
+}
+
+// lookup returns the node of a clean path, or nil if it does not exist.
+func (m *MemoryFileStation) lookup(path string) *memoryNode {
+	parts := strings.Split(strings.TrimPrefix(path, "/"), "/")
+
+	n := m.shares[parts[0]]
+	for _, part := range parts[1:] {
+		if n == nil || !n.folder {
+			return nil
+		}
+		n = n.children[part]
+	}
+
+	return n
+}
+
+// folderForWrite returns the folder, if the user is permitted
+// to change its content.
+func (m *MemoryFileStation) folderForWrite(path string) (*memoryNode, FileStationStatus) {
+	n := m.lookup(path)
+	if n == nil || !n.folder {
+		return nil, WFM2_FILE_NO_EXIST
+	}
+	if !m.allowed(n, 02) {
+		return nil, WFM2_PERMISSION_DENY
+	}
+
+	return n, WFM2_SUCCESS
+}
+
+// allowed checks the privilege of the node for the user,
+// e.g. 04 for reading and 02 for writing.
+func (m *MemoryFileStation) allowed(n *memoryNode, bits Privilege) bool {
+	if m.User == "admin" {
+		return true
+	}
+
+	p := n.privilege
+	switch {
+	case n.owner == m.User:
+		p >>= 6
+	case n.group == m.Group:
+		p >>= 3
+	}
+
+	return p&bits == bits
+}
+
+func newMemoryNode(name string, folder bool, owner, group string) *memoryNode {
+	n := &memoryNode{
+		name:      name,
+		folder:    folder,
+		owner:     owner,
+		group:     group,
+		privilege: 0666,
+		modTime:   time.Now(),
+	}
+
+	if folder {
+		n.privilege = 0777
+		n.children = make(map[string]*memoryNode)
+	}
+
+	return n
+}
+
+func (n *memoryNode) setPrivilege(privilege Privilege, recursive bool) {
+	n.privilege = privilege
+
+	if recursive {
+		for _, c := range n.children {
+			c.setPrivilege(privilege, true)
+		}
+	}
+}
+
+// entry returns the node as reported by get_list and stat.
+func (n *memoryNode) entry(path string) FileListEntry {
+	e := FileListEntry{
+		Name:         n.name,
+		FullPath:     path,
+		Exists:       1,
+		FileSize:     int64(len(n.content)),
+		Owner:        n.owner,
+		Group:        n.group,
+		Privilege:    n.privilege.String(),
+		ModifiedDate: int(n.modTime.Unix()),
+	}
+
+	if n.folder {
+		e.IsFolder = 1
+	}
+
+	return e
+}
+
+// folderEntry returns the node as reported by get_tree.
+func (n *memoryNode) folderEntry(path string) FolderListEntry {
+	return FolderListEntry{
+		Path:      path,
+		Text:      n.name,
+		ItemCount: len(n.children),
+	}
+}
+
+// sortEntries sorts the entries the same way as get_list.
+func sortEntries(entries []FileListEntry, opts *ListOptions) {
+	sort.SliceStable(entries, func(i, j int) bool {
+		a, b := &entries[i], &entries[j]
+		if opts.Descending {
+			a, b = b, a
+		}
+
+		switch opts.SortBy {
+		case SortField_Size:
+			if a.FileSize != b.FileSize {
+				return a.FileSize < b.FileSize
+			}
+		case SortField_ModTime:
+			if a.ModifiedDate != b.ModifiedDate {
+				return a.ModifiedDate < b.ModifiedDate
+			}
+		case SortField_Type:
+			if ea, eb := strings.ToLower(path.Ext(a.Name)), strings.ToLower(path.Ext(b.Name)); ea != eb {
+				return ea < eb
+			}
+		}
+
+		return a.Name < b.Name
+	})
+}
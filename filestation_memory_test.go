@@ -0,0 +1,242 @@
+package filestation
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/fs"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func createMemoryTestFileStation(t *testing.T) *MemoryFileStation {
+	m := NewMemoryFileStation()
+
+	if err := m.AddShare("share", true); err != nil {
+		t.Fatalf("Failed to add share: %v", err)
+	}
+	if err := m.AddShare("nobin", false); err != nil {
+		t.Fatalf("Failed to add share: %v", err)
+	}
+
+	return m
+}
+
+func TestMemoryCreateFolder(t *testing.T) {
+	m := createMemoryTestFileStation(t)
+
+	created, err := m.CreateFolder("/share/folder")
+	if err != nil || !created {
+		t.Fatalf("Failed to create folder: %v %v", created, err)
+	}
+
+	created, err = m.CreateFolder("/share/folder")
+	if err != nil || created {
+		t.Fatalf("Expected existing folder not to be created: %v %v", created, err)
+	}
+
+	if _, err := m.CreateFolder("/share/missing/folder"); !errors.Is(err, fs.ErrNotExist) {
+		t.Fatalf("Expected missing parent to fail: %v", err)
+	}
+	if _, err := m.CreateFolder("/share/in:valid"); !errors.Is(err, WFM2_ILLEGAL_NAME) {
+		t.Fatalf("Expected illegal name to fail: %v", err)
+	}
+
+	count, err := m.EnsureFolder("/share/a/b/c")
+	if err != nil || count != 3 {
+		t.Fatalf("Failed to ensure folder: %v %v", count, err)
+	}
+
+	stat, err := m.GetFileStat("/share/a/b")
+	if err != nil || stat == nil || !stat.IsDir() || stat.Owner != "admin" || stat.Perm() != 0777 {
+		t.Fatalf("Wrong stat: %+v %v", stat, err)
+	}
+}
+
+func TestMemoryUploadDownload(t *testing.T) {
+	m := createMemoryTestFileStation(t)
+
+	if err := m.Upload("/share/file.txt", strings.NewReader("hello"), false); err != nil {
+		t.Fatalf("Failed to upload: %v", err)
+	}
+	if err := m.Upload("/share/file.txt", strings.NewReader("again"), false); !errors.Is(err, fs.ErrExist) {
+		t.Fatalf("Expected upload without overwrite to fail: %v", err)
+	}
+	if err := m.Upload("/share/file.txt", strings.NewReader("world"), true); err != nil {
+		t.Fatalf("Failed to overwrite: %v", err)
+	}
+
+	r, err := m.Download("/share/file.txt")
+	if err != nil {
+		t.Fatalf("Failed to download: %v", err)
+	}
+	content, _ := io.ReadAll(r)
+	r.Close()
+	if string(content) != "world" {
+		t.Fatalf("Wrong content: %q", content)
+	}
+
+	if _, err := m.Download("/share/missing.txt"); !errors.Is(err, fs.ErrNotExist) {
+		t.Fatalf("Expected missing file to fail: %v", err)
+	}
+}
+
+func TestMemoryDelete(t *testing.T) {
+	m := createMemoryTestFileStation(t)
+
+	m.EnsureFolder("/share/a/b")
+	m.Upload("/share/a/b/file.txt", strings.NewReader("data"), false)
+	m.EnsureFolder("/nobin/a")
+
+	result, err := m.Delete("/share/a/b/file.txt", false)
+	if err != nil || result != DeleteResult_MovedToRecycleBin {
+		t.Fatalf("Wrong result: %v %v", result, err)
+	}
+	if stat, _ := m.GetFileStat("/share/@Recycle/a/b/file.txt"); stat == nil {
+		t.Fatal("Expected file to be in the recycle bin")
+	}
+
+	result, err = m.Delete("/share/@Recycle/a", false)
+	if err != nil || result != DeleteResult_Deleted {
+		t.Fatalf("Wrong result within recycle bin: %v %v", result, err)
+	}
+
+	result, err = m.Delete("/nobin/a", false)
+	if err != nil || result != DeleteResult_Deleted {
+		t.Fatalf("Wrong result without recycle bin: %v %v", result, err)
+	}
+
+	result, err = m.Delete("/nobin/a", false)
+	if err != nil || result != DeleteResult_NotFound {
+		t.Fatalf("Wrong result for missing file: %v %v", result, err)
+	}
+
+	deleted, err := m.DeleteFileNoRecycleBin("/share/a")
+	if err != nil || !deleted {
+		t.Fatalf("Failed to delete: %v %v", deleted, err)
+	}
+	deleted, err = m.DeleteFile("/share/a")
+	if err != nil || deleted {
+		t.Fatalf("Expected missing folder not to be deleted: %v %v", deleted, err)
+	}
+	if _, err := m.DeleteFile("/share/missing/a"); !errors.Is(err, fs.ErrNotExist) {
+		t.Fatalf("Expected missing parent folder to fail: %v", err)
+	}
+	if stat, _ := m.GetFileStat("/share/@Recycle/a/b"); stat != nil {
+		t.Fatal("Expected folder not to be in the recycle bin")
+	}
+}
+
+func TestMemoryPrivileges(t *testing.T) {
+	m := createMemoryTestFileStation(t)
+
+	m.EnsureFolder("/share/private/sub")
+	if err := m.SetPrivilege("/share/private", 0750, true); err != nil {
+		t.Fatalf("Failed to set privilege: %v", err)
+	}
+	if stat, _ := m.GetFileStat("/share/private/sub"); stat.Perm() != 0750 {
+		t.Fatalf("Expected recursive privilege, got %v", stat.Perm())
+	}
+
+	m.User = "guest"
+	m.Group = "everyone"
+
+	if _, err := m.GetFileList("/share/private"); !errors.Is(err, fs.ErrPermission) {
+		t.Fatalf("Expected listing to be denied: %v", err)
+	}
+	if _, err := m.CreateFolder("/share/private/other"); !errors.Is(err, fs.ErrPermission) {
+		t.Fatalf("Expected creating to be denied: %v", err)
+	}
+	if err := m.SetPrivilege("/share/private", 0777, false); !errors.Is(err, fs.ErrPermission) {
+		t.Fatalf("Expected changing privilege to be denied: %v", err)
+	}
+	if result, err := m.Delete("/share/private/sub", false); err != nil || result != DeleteResult_PermissionDenied {
+		t.Fatalf("Wrong result: %v %v", result, err)
+	}
+
+	if err := m.SetOwner("/share/private", "guest", "everyone"); err != nil {
+		t.Fatalf("Failed to set owner: %v", err)
+	}
+	if created, err := m.CreateFolder("/share/private/other"); err != nil || !created {
+		t.Fatalf("Expected owner to create folder: %v %v", created, err)
+	}
+	if stat, _ := m.GetFileStat("/share/private/other"); stat.Owner != "guest" || stat.Group != "everyone" {
+		t.Fatalf("Wrong owner: %+v", stat)
+	}
+}
+
+func TestMemoryRenameMove(t *testing.T) {
+	m := createMemoryTestFileStation(t)
+
+	m.EnsureFolder("/share/a/b")
+	m.EnsureFolder("/share/c")
+	m.Upload("/share/a/file.txt", strings.NewReader("data"), false)
+
+	if err := m.Rename("/share/a/file.txt", "renamed.txt"); err != nil {
+		t.Fatalf("Failed to rename: %v", err)
+	}
+	if err := m.Rename("/share/a/renamed.txt", "b"); !errors.Is(err, fs.ErrExist) {
+		t.Fatalf("Expected rename to existing name to fail: %v", err)
+	}
+	if err := m.Move("/share/a/renamed.txt", "/share/c"); err != nil {
+		t.Fatalf("Failed to move: %v", err)
+	}
+	if err := m.Move("/share/a", "/share/a/b"); err == nil {
+		t.Fatal("Expected move into itself to fail")
+	}
+	if err := m.Move("/share/a", "/share/missing"); !errors.Is(err, fs.ErrNotExist) {
+		t.Fatalf("Expected move to missing folder to fail: %v", err)
+	}
+
+	if stat, _ := m.GetFileStat("/share/c/renamed.txt"); stat == nil || stat.Size() != 4 {
+		t.Fatalf("Wrong moved file: %+v", stat)
+	}
+}
+
+func TestMemoryListing(t *testing.T) {
+	m := createMemoryTestFileStation(t)
+
+	m.EnsureFolder("/share/a/b")
+	m.Upload("/share/a/small.txt", strings.NewReader("1"), false)
+	m.Upload("/share/a/large.txt", strings.NewReader("12345"), false)
+
+	list, err := m.GetFileListWithOptions("/share/a", ListOptions{SortBy: SortField_Size, Descending: true, FilesOnly: true})
+	if err != nil || len(list) != 2 || list[0].Name != "large.txt" || list[0].FullPath != "/share/a/large.txt" {
+		t.Fatalf("Wrong listing: %+v %v", list, err)
+	}
+
+	count := 0
+	err = m.Walk(context.Background(), "/share", func(path string, entry *FileListEntry, err error) error {
+		count++
+		return err
+	}, WalkOptions{Concurrency: 4})
+	if err != nil || count != 4 {
+		t.Fatalf("Wrong walk: %v %v", count, err)
+	}
+
+	tree, err := m.GetFolderTree(context.Background(), "/", 3)
+	if err != nil || len(tree.Children) != 2 || tree.Children[1].Path != "/share" {
+		t.Fatalf("Wrong tree: %+v %v", tree, err)
+	}
+	if a := tree.Children[1].Children[0]; a.Path != "/share/a" || a.ItemCount != 3 || len(a.Children) != 1 {
+		t.Fatalf("Wrong tree node: %+v", a)
+	}
+
+	shares, err := m.GetShareList()
+	if err != nil || len(shares) != 2 || shares[1].RecycleBin != "1" {
+		t.Fatalf("Wrong shares: %+v %v", shares, err)
+	}
+}
+
+func TestMemoryFS(t *testing.T) {
+	m := createMemoryTestFileStation(t)
+
+	m.EnsureFolder("/share/a/b")
+	m.Upload("/share/a/file.txt", strings.NewReader("data"), false)
+	m.Upload("/share/top.txt", strings.NewReader("top"), false)
+
+	if err := fstest.TestFS(NewFS(m, "/share"), "a/file.txt", "a/b", "top.txt"); err != nil {
+		t.Fatal(err)
+	}
+}
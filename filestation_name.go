@@ -0,0 +1,107 @@
+package filestation
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// MaxNameLength is the maximum length of a file or folder name in bytes.
+const MaxNameLength = 255
+
+// illegalNameChars contains the characters, which are not allowed
+// in file and folder names.
+const illegalNameChars = "\"+=/\\:|*?<>;[]%,`'"
+
+// illegalNamePrefix is reserved for snapshots.
+const illegalNamePrefix = "_sn_"
+
+// NameError describes an invalid file or folder name.
+// It matches the status the QNAP system would return,
+// e.g. errors.Is(err, WFM2_ILLEGAL_NAME).
+type NameError struct {
+	Name   string
+	Reason string
+	Status FileStationStatus
+}
+
+func (e *NameError) Error() string {
+	return fmt.Sprintf("invalid name %q: %v", e.Name, e.Reason)
+}
+
+// Unwrap returns the status the QNAP system would return.
+func (e *NameError) Unwrap() error {
+	return e.Status
+}
+
+// ValidateName checks if the file or folder name is accepted
+// by the QNAP system.
+func ValidateName(name string) error {
+	switch {
+	case name == "" || name == "." || name == "..":
+		return &NameError{Name: name, Reason: "name is empty or reserved", Status: WFM2_ILLEGAL_NAME}
+	case len(name) > MaxNameLength:
+		return &NameError{Name: name, Reason: fmt.Sprintf("name is longer than %v bytes", MaxNameLength), Status: WFM2_FILE_NAME_TOO_LONG}
+	case !utf8.ValidString(name):
+		return &NameError{Name: name, Reason: "name is not valid UTF-8", Status: WFM2_ILLEGAL_NAME}
+	case strings.HasPrefix(name, illegalNamePrefix):
+		return &NameError{Name: name, Reason: fmt.Sprintf("name begins with reserved prefix %q", illegalNamePrefix), Status: WFM2_ILLEGAL_NAME}
+	}
+
+	if i := strings.IndexAny(name, illegalNameChars); i >= 0 {
+		return &NameError{Name: name, Reason: fmt.Sprintf("name contains illegal character %q", name[i]), Status: WFM2_ILLEGAL_NAME}
+	}
+
+	return nil
+}
+
+// SanitizeName converts a file or folder name into a name,
+// which is accepted by the QNAP system. Illegal characters are replaced
+// by underscores and names exceeding MaxNameLength bytes are truncated
+// (keeping the file extension, if possible) without splitting
+// multi-byte UTF-8 characters.
+func SanitizeName(name string) string {
+	name = strings.ToValidUTF8(name, "_")
+
+	name = strings.Map(func(r rune) rune {
+		if strings.ContainsRune(illegalNameChars, r) {
+			return '_'
+		}
+		return r
+	}, name)
+
+	if strings.HasPrefix(name, illegalNamePrefix) {
+		name = "sn_" + name[len(illegalNamePrefix):]
+	}
+
+	if name == "" || name == "." || name == ".." {
+		return "_"
+	}
+
+	if len(name) <= MaxNameLength {
+		return name
+	}
+
+	// keep a short extension
+	ext := ""
+	if i := strings.LastIndexByte(name, '.'); i > 0 && len(name)-i <= 16 {
+		ext = name[i:]
+		name = name[:i]
+	}
+
+	return truncateUTF8(name, MaxNameLength-len(ext)) + ext
+}
+
+// truncateUTF8 shortens the string to at most n bytes
+// without splitting a multi-byte character.
+func truncateUTF8(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+
+	return s[:n]
+}
@@ -0,0 +1,57 @@
+package filestation
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestValidateName(t *testing.T) {
+	valid := []string{"test", "report 2020.pdf", "日本語", "_snapshot", "a.b.c"}
+	for _, name := range valid {
+		if err := ValidateName(name); err != nil {
+			t.Errorf("ValidateName(%q) failed: %v", name, err)
+		}
+	}
+
+	invalid := []string{"", ".", "..", "a:b", "a/b", `a\b`, "a*", "a?", "a<b>", "a;b", "[a]", "50%", "a,b", "a`b", "a'b", `a"b`, "a+b", "a=b", "a|b", "_sn_test", "_sn_bk1"}
+	for _, name := range invalid {
+		err := ValidateName(name)
+		if !errors.Is(err, WFM2_ILLEGAL_NAME) {
+			t.Errorf("ValidateName(%q) expected to fail, got %v", name, err)
+		}
+	}
+
+	if err := ValidateName(strings.Repeat("a", MaxNameLength+1)); !errors.Is(err, WFM2_FILE_NAME_TOO_LONG) {
+		t.Errorf("expected too long name to fail, got %v", err)
+	}
+}
+
+func TestSanitizeName(t *testing.T) {
+	tests := []struct {
+		name, expected string
+	}{
+		{"test", "test"},
+		{"a:b/c", "a_b_c"},
+		{"_sn_test", "sn_test"},
+		{"", "_"},
+		{"..", "_"},
+	}
+
+	for _, tt := range tests {
+		if r := SanitizeName(tt.name); r != tt.expected {
+			t.Errorf("SanitizeName(%q) = %q, expected %q", tt.name, r, tt.expected)
+		}
+	}
+
+	// multi-byte truncation
+	long := strings.Repeat("日", 100) + ".txt"
+	r := SanitizeName(long)
+	if len(r) > MaxNameLength || !utf8.ValidString(r) || !strings.HasSuffix(r, ".txt") {
+		t.Fatalf("wrong truncation: %v (%v bytes)", r, len(r))
+	}
+	if err := ValidateName(r); err != nil {
+		t.Fatalf("sanitized name is invalid: %v", err)
+	}
+}
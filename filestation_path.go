@@ -0,0 +1,78 @@
+package filestation
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+)
+
+// ErrInvalidPath is returned for remote paths, which are not absolute,
+// do not contain a share or leave the share by using "..".
+var ErrInvalidPath = errors.New("invalid path")
+
+// CleanPath validates and normalizes a remote path on the QNAP system.
+// Remote paths always use forward slashes, begin with the share,
+// e.g. "/Public/documents", independent of the local operating system.
+//
+// Duplicate slashes, "." and ".." are resolved. Paths which do not
+// contain a share, or which leave the share by using "..", are rejected.
+func CleanPath(p string) (string, error) {
+	if !strings.HasPrefix(p, "/") {
+		return "", fmt.Errorf("%w: path does not begin with a slash: %v", ErrInvalidPath, p)
+	}
+
+	var parts []string
+	for _, part := range strings.Split(p, "/") {
+		switch part {
+		case "", ".":
+			continue
+		case "..":
+			if len(parts) <= 1 {
+				return "", fmt.Errorf("%w: path leaves the share: %v", ErrInvalidPath, p)
+			}
+			parts = parts[:len(parts)-1]
+		default:
+			parts = append(parts, part)
+		}
+	}
+
+	if len(parts) == 0 {
+		return "", fmt.Errorf("%w: path does not contain a share: %v", ErrInvalidPath, p)
+	}
+
+	return "/" + strings.Join(parts, "/"), nil
+}
+
+// ShareOf returns the share of a remote path, e.g. "/Public"
+// for "/Public/documents".
+func ShareOf(p string) (string, error) {
+	p, err := CleanPath(p)
+	if err != nil {
+		return "", err
+	}
+
+	if i := strings.IndexByte(p[1:], '/'); i >= 0 {
+		return p[:i+1], nil
+	}
+	return p, nil
+}
+
+// checkPath cleans the remote path and reports invalid paths as *Error.
+func checkPath(op, p string) (string, error) {
+	clean, err := CleanPath(p)
+	if err != nil {
+		return "", newError(op, p, nil, err)
+	}
+	return clean, nil
+}
+
+// splitPath splits a clean remote path into the parent folder and name.
+func splitPath(p string) (dir, name string) {
+	return path.Dir(p), path.Base(p)
+}
+
+// joinPath appends a name to a clean remote path.
+func joinPath(p, name string) string {
+	return path.Join(p, name)
+}
@@ -0,0 +1,72 @@
+package filestation
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestCleanPath(t *testing.T) {
+	tests := []struct {
+		path, expected string
+	}{
+		{"/Public", "/Public"},
+		{"/Public/", "/Public"},
+		{"//Public//documents///test", "/Public/documents/test"},
+		{"/Public/./documents", "/Public/documents"},
+		{"/Public/documents/../test", "/Public/test"},
+		{"/Public/C:/test", "/Public/C:/test"},
+	}
+
+	for _, tt := range tests {
+		p, err := CleanPath(tt.path)
+		if err != nil {
+			t.Errorf("CleanPath(%q) failed: %v", tt.path, err)
+			continue
+		}
+		if p != tt.expected {
+			t.Errorf("CleanPath(%q) = %q, expected %q", tt.path, p, tt.expected)
+		}
+	}
+
+	invalid := []string{
+		"",
+		"/",
+		"Public/test",
+		`C:\Public\test`,
+		"/Public/..",
+		"/Public/../Other",
+		"/Public/test/../../..",
+	}
+
+	for _, p := range invalid {
+		if _, err := CleanPath(p); !errors.Is(err, ErrInvalidPath) {
+			t.Errorf("CleanPath(%q) expected to fail, got %v", p, err)
+		}
+	}
+}
+
+func TestShareOf(t *testing.T) {
+	share, err := ShareOf("/Public/documents/test")
+	if err != nil || share != "/Public" {
+		t.Fatalf("wrong share: %v %v", share, err)
+	}
+
+	share, err = ShareOf("/Public")
+	if err != nil || share != "/Public" {
+		t.Fatalf("wrong share: %v %v", share, err)
+	}
+}
+
+func TestInvalidPathRejected(t *testing.T) {
+	s := &FileStationSession{}
+
+	if _, err := s.CreateFolder("/Public/../../etc"); !errors.Is(err, ErrInvalidPath) {
+		t.Fatalf("Expected invalid path error, got %v", err)
+	}
+	if _, err := s.GetFileStat("relative/path"); !errors.Is(err, ErrInvalidPath) {
+		t.Fatalf("Expected invalid path error, got %v", err)
+	}
+	if _, err := s.GetFileList("/"); !errors.Is(err, ErrInvalidPath) {
+		t.Fatalf("Expected invalid path error, got %v", err)
+	}
+}
@@ -0,0 +1,162 @@
+package filestation
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/go-resty/resty/v2"
+	"io"
+	"math/rand"
+	"net"
+	"reflect"
+	"time"
+)
+
+// RetryPolicy controls the retries of API calls, which failed temporarily,
+// e.g. because the QNAP system is busy.
+type RetryPolicy struct {
+	// MaxAttempts is the maximum number of attempts, including the first one.
+	// A value of 1 or less disables retries.
+	MaxAttempts int
+
+	// InitialBackoff is the delay before the first retry. It is doubled
+	// for every further retry, up to MaxBackoff.
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+
+	// Jitter is the fraction (0.0 to 1.0) of the backoff, which is randomized.
+	Jitter float64
+
+	// RetryMutating enables retries for operations, which modify data
+	// (e.g. CreateFolder, DeleteFile). By default, only operations which
+	// read data are retried.
+	RetryMutating bool
+
+	// IsTemporary overrides the classification of temporary errors.
+	// By default, IsTemporaryError() is used.
+	IsTemporary func(err error) bool
+}
+
+// idempotentFuncs lists the utilRequest.cgi functions,
+// which are safe to be retried.
+var idempotentFuncs = map[string]bool{
+	"get_tree": true,
+	"get_list": true,
+	"stat":     true,
+	"download": true,
+}
+
+// IsTemporaryError checks if the error is likely to disappear when the
+// operation is retried. This includes temporary File Station status codes,
+// HTTP 5xx responses and network timeouts.
+func IsTemporaryError(err error) bool {
+	var status FileStationStatus
+	if errors.As(err, &status) {
+		return status.Temporary()
+	}
+
+	var e *Error
+	if errors.As(err, &e) && e.HTTPStatus >= 500 {
+		return true
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return true
+	}
+
+	return false
+}
+
+func (p *RetryPolicy) isTemporary(err error) bool {
+	if p.IsTemporary != nil {
+		return p.IsTemporary(err)
+	}
+	return IsTemporaryError(err)
+}
+
+// backoff returns the delay before the given retry (starting at 1).
+func (p *RetryPolicy) backoff(retry int) time.Duration {
+	initial := p.InitialBackoff
+	if initial <= 0 {
+		initial = 500 * time.Millisecond
+	}
+	max := p.MaxBackoff
+	if max <= 0 {
+		max = 10 * time.Second
+	}
+
+	d := initial
+	for i := 1; i < retry && d < max; i++ {
+		d *= 2
+	}
+	if d > max {
+		d = max
+	}
+
+	if p.Jitter > 0 {
+		j := time.Duration(float64(d) * p.Jitter)
+		if j > 0 {
+			d = d - j + time.Duration(rand.Int63n(int64(j)+1))
+		}
+	}
+
+	return d
+}
+
+// statusOf returns the status code of the response, if it contains one.
+func statusOf(res *resty.Response) (FileStationStatus, bool) {
+	var probe struct {
+		Status *FileStationStatus `json:"status"`
+	}
+
+	if err := json.Unmarshal(res.Body(), &probe); err != nil || probe.Status == nil {
+		return 0, false
+	}
+
+	return *probe.Status, true
+}
+
+// resetResult clears the result of a previous attempt.
+func resetResult(req *resty.Request) {
+	v := reflect.ValueOf(req.Result)
+	if v.Kind() == reflect.Ptr && !v.IsNil() {
+		v.Elem().Set(reflect.Zero(v.Elem().Type()))
+	}
+}
+
+// rewinder returns a function, which prepares the body for being sent
+// again. It reports false if the body cannot be repeated. A nil body
+// can always be repeated.
+func rewinder(body io.Reader) func() bool {
+	if body == nil {
+		return func() bool { return true }
+	}
+
+	seeker, ok := body.(io.Seeker)
+	if !ok {
+		return func() bool { return false }
+	}
+
+	offset, err := seeker.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return func() bool { return false }
+	}
+
+	return func() bool {
+		_, err := seeker.Seek(offset, io.SeekStart)
+		return err == nil
+	}
+}
+
+// sleep waits for the given duration, unless the request is cancelled.
+func sleep(req *resty.Request, d time.Duration) bool {
+	t := time.NewTimer(d)
+	defer t.Stop()
+
+	select {
+	case <-t.C:
+		return true
+	case <-req.Context().Done():
+		return false
+	}
+}
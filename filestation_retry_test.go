@@ -0,0 +1,139 @@
+package filestation
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// createRetryTestSession connects to a stub server, which fails the
+// first calls with the given status.
+func createRetryTestSession(t *testing.T, failures int32, status FileStationStatus, policy *RetryPolicy) (*FileStationSession, *int32) {
+	var calls int32
+
+	s, _ := createStubSession(t, &ConfigOptions{
+		APICallTimeout: 5 * time.Second,
+		RetryPolicy:    policy,
+	}, func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) <= failures {
+			fmt.Fprintf(w, `{"status":%v}`, int(status))
+			return
+		}
+		if r.URL.Query().Get("func") == "get_tree" {
+			w.Write([]byte(`[{"id":"/share"}]`))
+			return
+		}
+		w.Write([]byte(`{"status":1}`))
+	})
+
+	return s, &calls
+}
+
+func TestRetryIdempotent(t *testing.T) {
+	s, calls := createRetryTestSession(t, 2, WFM2_DB_FAIL, &RetryPolicy{
+		MaxAttempts:    3,
+		InitialBackoff: time.Millisecond,
+	})
+
+	shares, err := s.GetShareList()
+	if err != nil {
+		t.Fatalf("Failed to retrieve share list: %v", err)
+	}
+	if len(shares) != 1 {
+		t.Fatalf("Expected one share, got %v", len(shares))
+	}
+	if *calls != 3 {
+		t.Fatalf("Expected 3 calls, got %v", *calls)
+	}
+}
+
+func TestRetryMutatingOptIn(t *testing.T) {
+	s, calls := createRetryTestSession(t, 1, WFM2_DB_FAIL, &RetryPolicy{
+		MaxAttempts:    3,
+		InitialBackoff: time.Millisecond,
+	})
+
+	_, err := s.CreateFolder("/share/test")
+	if err == nil || !IsTemporaryError(err) {
+		t.Fatalf("Expected temporary error, got %v", err)
+	}
+	if *calls != 1 {
+		t.Fatalf("Expected 1 call, got %v", *calls)
+	}
+
+	s, calls = createRetryTestSession(t, 1, WFM2_DB_FAIL, &RetryPolicy{
+		MaxAttempts:    3,
+		InitialBackoff: time.Millisecond,
+		RetryMutating:  true,
+	})
+
+	created, err := s.CreateFolder("/share/test")
+	if err != nil || !created {
+		t.Fatalf("Expected folder to be created, got %v", err)
+	}
+	if *calls != 2 {
+		t.Fatalf("Expected 2 calls, got %v", *calls)
+	}
+}
+
+func TestRetryNotTemporary(t *testing.T) {
+	s, calls := createRetryTestSession(t, 1, WFM2_PERMISSION_DENY, &RetryPolicy{
+		MaxAttempts:    3,
+		InitialBackoff: time.Millisecond,
+	})
+
+	if _, err := s.GetShareList(); !errors.Is(err, WFM2_PERMISSION_DENY) {
+		t.Fatalf("Expected permission error, got %v", err)
+	}
+	if *calls != 1 {
+		t.Fatalf("Expected 1 call, got %v", *calls)
+	}
+}
+
+func TestRetryIsTemporary(t *testing.T) {
+	tests := []struct {
+		status      FileStationStatus
+		isTemporary func(err error) bool
+		calls       int32
+	}{
+		{WFM2_DB_FAIL, nil, 2},
+		{WFM2_DB_FAIL, func(err error) bool { return false }, 1},
+		{WFM2_PERMISSION_DENY, nil, 1},
+		{WFM2_PERMISSION_DENY, func(err error) bool { return errors.Is(err, WFM2_PERMISSION_DENY) }, 2},
+	}
+
+	for _, tt := range tests {
+		s, calls := createRetryTestSession(t, 1, tt.status, &RetryPolicy{
+			MaxAttempts:    3,
+			InitialBackoff: time.Millisecond,
+			IsTemporary:    tt.isTemporary,
+		})
+
+		s.GetShareList()
+
+		if *calls != tt.calls {
+			t.Errorf("%v (override %v): expected %v calls, got %v", tt.status, tt.isTemporary != nil, tt.calls, *calls)
+		}
+	}
+}
+
+func TestRetryBackoff(t *testing.T) {
+	p := &RetryPolicy{InitialBackoff: time.Second, MaxBackoff: 5 * time.Second}
+
+	expected := []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 5 * time.Second, 5 * time.Second}
+	for i, e := range expected {
+		if d := p.backoff(i + 1); d != e {
+			t.Errorf("backoff(%v) = %v, expected %v", i+1, d, e)
+		}
+	}
+
+	p.Jitter = 0.5
+	for i := 0; i < 100; i++ {
+		if d := p.backoff(1); d < 500*time.Millisecond || d > time.Second {
+			t.Fatalf("backoff with jitter out of range: %v", d)
+		}
+	}
+}
@@ -0,0 +1,125 @@
+package filestation
+
+import (
+	"strconv"
+	"strings"
+)
+
+// ServerInfo contains information about the QNAP system,
+// as reported during login.
+type ServerInfo struct {
+	Version    string // firmware version, e.g. "4.3.6"
+	Build      string // firmware build, e.g. "20190919"
+	AdminGroup bool   // the user is a member of the administrators group
+}
+
+// Capability is a feature of the File Station API, which is not
+// available on every firmware version.
+type Capability int
+
+const (
+	// CapabilityPOSTLogin indicates that the server accepts
+	// the login credentials as form data.
+	CapabilityPOSTLogin Capability = iota + 1
+
+	// CapabilityQTS5 indicates a QTS 5.x (or QuTS hero h5.x) firmware,
+	// as reported during login. It is informational only; the library
+	// uses the same API endpoints for all firmware versions.
+	CapabilityQTS5
+
+	// CapabilityQToken indicates that the server issued a persistent
+	// login token, which allows using Relogin().
+	CapabilityQToken
+)
+
+// PasswordEncoding specifies how the password is sent during login.
+type PasswordEncoding int
+
+const (
+	// PasswordEncodingAuto is the default, which is PasswordEncodingBase64.
+	PasswordEncodingAuto PasswordEncoding = iota
+
+	// PasswordEncodingBase64 sends the password base64-encoded,
+	// as documented for the File Station API.
+	PasswordEncodingBase64
+
+	// PasswordEncodingPlain sends the password unmodified in the form data,
+	// for firmware which does not accept the base64 encoding.
+	// It is never sent as query parameter, so the GET login fallback
+	// (see ConfigOptions.LegacyGETLogin) is not available.
+	PasswordEncodingPlain
+)
+
+// ServerInfo returns the information about the QNAP system
+// the session is connected to.
+func (s *FileStationSession) ServerInfo() ServerInfo {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.serverInfo
+}
+
+// Supports checks if the QNAP system provides a feature.
+// An unknown firmware version is treated as the oldest supported one.
+func (s *FileStationSession) Supports(c Capability) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	switch c {
+	case CapabilityPOSTLogin:
+		return s.postLogin
+	case CapabilityQTS5:
+		return compareVersion(s.serverInfo.Version, "5.0.0") >= 0
+	case CapabilityQToken:
+		return s.qtoken != ""
+	}
+
+	return false
+}
+
+// parseVersion splits a firmware version like "4.3.6" or "h5.0.1"
+// into its numeric parts. Non-numeric prefixes and suffixes are ignored.
+func parseVersion(v string) []int {
+	v = strings.TrimLeftFunc(v, func(r rune) bool { return r < '0' || r > '9' })
+
+	var ret []int
+	for _, p := range strings.Split(v, ".") {
+		end := strings.IndexFunc(p, func(r rune) bool { return r < '0' || r > '9' })
+		if end >= 0 {
+			p = p[:end]
+		}
+
+		n, err := strconv.Atoi(p)
+		if err != nil {
+			break
+		}
+		ret = append(ret, n)
+	}
+
+	return ret
+}
+
+// compareVersion compares two firmware versions and returns
+// -1, 0 or +1, like strings.Compare().
+func compareVersion(a, b string) int {
+	pa, pb := parseVersion(a), parseVersion(b)
+
+	for i := 0; i < len(pa) || i < len(pb); i++ {
+		var na, nb int
+		if i < len(pa) {
+			na = pa[i]
+		}
+		if i < len(pb) {
+			nb = pb[i]
+		}
+
+		if na < nb {
+			return -1
+		}
+		if na > nb {
+			return 1
+		}
+	}
+
+	return 0
+}
@@ -0,0 +1,39 @@
+package filestation
+
+import "testing"
+
+func TestCompareVersion(t *testing.T) {
+	tests := []struct {
+		a, b     string
+		expected int
+	}{
+		{"4.3.6", "5.0.0", -1},
+		{"5.0.1", "5.0.0", 1},
+		{"5.0", "5.0.0", 0},
+		{"h5.0.1", "5.0.0", 1},
+		{"h4.5.4", "5.0.0", -1},
+		{"5.1.0.2466", "5.1.0", 1},
+		{"", "5.0.0", -1},
+	}
+
+	for _, tt := range tests {
+		if r := compareVersion(tt.a, tt.b); r != tt.expected {
+			t.Errorf("compareVersion(%q, %q) = %v, expected %v", tt.a, tt.b, r, tt.expected)
+		}
+	}
+}
+
+func TestSupports(t *testing.T) {
+	s := &FileStationSession{serverInfo: ServerInfo{Version: "5.0.1"}}
+	if !s.Supports(CapabilityQTS5) {
+		t.Fatal("expected QTS 5 capability")
+	}
+	if s.Supports(CapabilityPOSTLogin) {
+		t.Fatal("expected no POST login capability")
+	}
+
+	s = &FileStationSession{serverInfo: ServerInfo{Version: "4.3.6"}}
+	if s.Supports(CapabilityQTS5) {
+		t.Fatal("expected no QTS 5 capability")
+	}
+}
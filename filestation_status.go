@@ -1,6 +1,9 @@
 package filestation
 
-import "fmt"
+import (
+	"fmt"
+	"io/fs"
+)
 
 type FileStationStatus int
 
@@ -163,3 +166,30 @@ func (s FileStationStatus) Error() string {
 
 	return fmt.Sprintf("WMF2_UNKNOWN:%v", int(s))
 }
+
+// Temporary checks if the status indicates a temporary failure,
+// e.g. because the QNAP system is busy, which is likely to disappear
+// when the operation is retried.
+func (s FileStationStatus) Temporary() bool {
+	switch s {
+	case WFM2_DB_FAIL, WFM2_PREPARE, WFM2_CLOUD_SERVER_ERROR:
+		return true
+	}
+
+	return false
+}
+
+// Is maps the status onto the io/fs sentinel errors, which allows
+// checks like errors.Is(err, fs.ErrNotExist).
+func (s FileStationStatus) Is(target error) bool {
+	switch target {
+	case fs.ErrNotExist:
+		return s == WFM2_FILE_NO_EXIST || s == WFM2_DES_FILE_NO_EXIST
+	case fs.ErrPermission:
+		return s == WFM2_PERMISSION_DENY || s == WFM2_SRC_PERMISSION_DENY || s == WFM2_DES_PERMISSION_DENY
+	case fs.ErrExist:
+		return s == WFM2_FILE_EXIST || s == WFM2_NAME_DUP
+	}
+
+	return false
+}
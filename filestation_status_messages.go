@@ -0,0 +1,112 @@
+package filestation
+
+import "strings"
+
+// Languages supported by FileStationStatus.Message().
+const (
+	LanguageEnglish            = "en"
+	LanguageTraditionalChinese = "zh-TW"
+)
+
+// statusMessages contains the human-readable status messages by language.
+var statusMessages = map[string]map[FileStationStatus]string{
+	LanguageEnglish: {
+		WFM2_FAIL:                        "An unknown error occurred.",
+		WFM2_SUCCESS:                     "Success.",
+		WFM2_FILE_EXIST:                  "The file already exists.",
+		WFM2_AUTH_FAIL:                   "Authentication failure.",
+		WFM2_PERMISSION_DENY:             "Permission denied.",
+		WFM2_FILE_NO_EXIST:               "The file or folder does not exist.",
+		WFM2_EXTRACTING:                  "The file is being extracted.",
+		WFM2_OPEN_FILE_FAIL:              "A file I/O error occurred.",
+		WFM2_DISABLE:                     "Web File Manager is not enabled.",
+		WFM2_QUOTA_ERROR:                 "You have reached the disk quota limit.",
+		WFM2_SRC_PERMISSION_DENY:         "You do not have permission to perform this action.",
+		WFM2_DES_PERMISSION_DENY:         "You do not have permission to perform this action.",
+		WFM2_ILLEGAL_NAME:                "The name is invalid. It must not contain the characters \" + = / \\ : | * ? < > ; [ ] % , ` ' or begin with \"_sn_\" or \"_sn_bk\".",
+		WFM2_EXCEED_ISO_MAX:              "The maximum number of allowed ISO shares is 256. Please unmount an ISO share first.",
+		WFM2_EXCEED_SHARE_MAX:            "The maximum number of shares is going to be exceeded.",
+		WFM2_NEED_CHECK:                  "The operation needs to be confirmed.",
+		WFM2_RECYCLE_BIN_NOT_ENABLE:      "The network recycle bin is not enabled.",
+		WFM2_CHECK_PASSWORD_FAIL:         "Enter password.",
+		WFM2_VIDEO_TCS_DISABLE:           "The multimedia library is not enabled.",
+		WFM2_DB_FAIL:                     "The system is currently busy. Please try again later.",
+		WFM2_PARAMETER_ERROR:             "There were input errors. Please try again later.",
+		WFM2_DEMO_SITE:                   "This operation is not available on the demo site.",
+		WFM2_TRANSCODE_ONGOING:           "Your files are now being transcoded.",
+		WFM2_SRC_VOLUME_ERROR:            "An error occurred in the source file. Please check and try again later.",
+		WFM2_DES_VOLUME_ERROR:            "A write error has occurred at the target destination. Please check and try again later.",
+		WFM2_DES_FILE_NO_EXIST:           "The target destination is unavailable. Please check and try again later.",
+		WFM2_FILE_NAME_TOO_LONG:          "The file name is too long. Please use a shorter one (maximum: 255 characters). Note that this length is for English characters. For non-English file names, please keep them shorter than the length above.",
+		WFM2_FOLDER_ENCRYPTION:           "This folder has been encrypted. Please decrypt it and try again.",
+		WFM2_PREPARE:                     "Processing now, please wait.",
+		WFM2_NO_SUPPORT_MEDIA:            "This file format is not supported.",
+		WFM2_DLNA_QDMS_DISABLE:           "Please enable the DLNA Media Server.",
+		WFM2_RENDER_NOT_FOUND:            "Cannot find any available DLNA devices.",
+		WFM2_CLOUD_SERVER_ERROR:          "The SmartLink service is currently busy. Please try again later.",
+		WFM2_NAME_DUP:                    "That folder or file name already exists. Please use another name.",
+		WFM2_EXCEED_SEARCH_MAX:           "The search returned more than 1000 results.",
+		WFM2_MEMORY_ERROR:                "The system is out of memory.",
+		WFM2_COMPRESSING:                 "The file is being compressed.",
+		WFM2_EXCEED_DAV_MAX:              "The maximum number of WebDAV connections has been reached.",
+		WFM2_UMOUNT_FAIL:                 "Failed to unmount.",
+		WFM2_MOUNT_FAIL:                  "Failed to mount.",
+		WFM2_WEBDAV_ACCOUNT_PASSWD_ERROR: "The WebDAV account or password is incorrect.",
+		WFM2_WEBDAV_SSL_ERROR:            "A WebDAV SSL error occurred.",
+		WFM2_WEBDAV_REMOUNT_ERROR:        "Failed to remount the WebDAV folder.",
+		WFM2_WEBDAV_HOST_ERROR:           "The WebDAV host cannot be reached.",
+		WFM2_WEBDAV_TIMEOUT_ERROR:        "The WebDAV connection timed out.",
+		WFM2_WEBDAV_CONF_ERROR:           "The WebDAV configuration is invalid.",
+		WFM2_WEBDAV_BASE_ERROR:           "A WebDAV error occurred.",
+	},
+	LanguageTraditionalChinese: {
+		WFM2_AUTH_FAIL:           "認證失敗",
+		WFM2_PERMISSION_DENY:     "存取拒絕",
+		WFM2_FILE_NO_EXIST:       "檔案不存在",
+		WFM2_EXTRACTING:          "檔案解壓縮中",
+		WFM2_OPEN_FILE_FAIL:      "檔案寫入時發生錯誤",
+		WFM2_DISABLE:             "Web File Manager尚未啟用",
+		WFM2_QUOTA_ERROR:         "您的磁碟容量配額已滿",
+		WFM2_SRC_PERMISSION_DENY: "您沒有權限進行此項操作",
+		WFM2_DES_PERMISSION_DENY: "您沒有權限進行此項操作",
+		WFM2_ILLEGAL_NAME:        "名稱不合法。因為其中含有以下字元：\" + = / \\ ： | * ? < > ; [ ] % , ` ' 字元或特殊字首 \"_sn_\" 和 \"_sn_bk\"。",
+		WFM2_EXCEED_ISO_MAX:      "最大支援的映像檔資料夾是256。請先卸載一個映像檔資料夾。",
+		WFM2_EXCEED_SHARE_MAX:    "分享的數目已到達最大的分享數目的限制",
+		WFM2_CHECK_PASSWORD_FAIL: "請輸入密碼",
+		WFM2_VIDEO_TCS_DISABLE:   "媒體櫃未啟動",
+		WFM2_DB_FAIL:             "系統忙碌中，請再試一次。",
+		WFM2_TRANSCODE_ONGOING:   "您的檔案正在轉檔中。",
+		WFM2_SRC_VOLUME_ERROR:    "資料來源讀取異常，請檢查資料來源後再試一次。",
+		WFM2_DES_VOLUME_ERROR:    "目的地寫入異常，請檢查後再試一次。",
+		WFM2_DES_FILE_NO_EXIST:   "目的地路徑不存在，請檢查後再試一次。",
+		WFM2_FILE_NAME_TOO_LONG:  "名稱長度超過限制，請將長度控制在255字元之內。請注意，此長度為英文字元長度。故針對非英語語系的檔案名稱，請注意勿超過此長度。",
+		WFM2_FOLDER_ENCRYPTION:   "資料夾已加密，請先解密。",
+		WFM2_PREPARE:             "任務進行中，請稍等。",
+		WFM2_NO_SUPPORT_MEDIA:    "不支援開啟這類型的格式。",
+		WFM2_DLNA_QDMS_DISABLE:   "請先啟動DLNA Media Server。",
+		WFM2_RENDER_NOT_FOUND:    "目前找不到任何可用的播放裝置。",
+		WFM2_CLOUD_SERVER_ERROR:  "SmartLink服務忙碌中，請再試一次。",
+		WFM2_EXCEED_SEARCH_MAX:   "搜尋結果超過1000筆",
+	},
+}
+
+// Message returns the human-readable message of the status in the
+// given language, e.g. "en" or "zh-TW". Messages, which are not available
+// in the language, are returned in English.
+func (s FileStationStatus) Message(lang string) string {
+	lang = strings.Replace(lang, "_", "-", -1)
+
+	for l, messages := range statusMessages {
+		if strings.EqualFold(l, lang) {
+			if msg, ok := messages[s]; ok {
+				return msg
+			}
+		}
+	}
+
+	if msg, ok := statusMessages[LanguageEnglish][s]; ok {
+		return msg
+	}
+
+	return s.Error()
+}
@@ -0,0 +1,74 @@
+package filestation
+
+import (
+	"errors"
+	"io/fs"
+	"testing"
+)
+
+func TestStatusIsFSError(t *testing.T) {
+	tests := []struct {
+		status FileStationStatus
+		target error
+	}{
+		{WFM2_FILE_NO_EXIST, fs.ErrNotExist},
+		{WFM2_DES_FILE_NO_EXIST, fs.ErrNotExist},
+		{WFM2_PERMISSION_DENY, fs.ErrPermission},
+		{WFM2_SRC_PERMISSION_DENY, fs.ErrPermission},
+		{WFM2_DES_PERMISSION_DENY, fs.ErrPermission},
+		{WFM2_FILE_EXIST, fs.ErrExist},
+		{WFM2_NAME_DUP, fs.ErrExist},
+	}
+
+	for _, tt := range tests {
+		err := newError("Test", "/share/test", nil, tt.status)
+		if !errors.Is(err, tt.target) {
+			t.Errorf("expected %v to match %v", tt.status, tt.target)
+		}
+	}
+
+	if errors.Is(WFM2_QUOTA_ERROR, fs.ErrNotExist) {
+		t.Fatal("expected WFM2_QUOTA_ERROR to not match fs.ErrNotExist")
+	}
+	if !errors.Is(WFM2_NAME_DUP, WFM2_NAME_DUP) {
+		t.Fatal("expected status to match itself")
+	}
+}
+
+func TestStatusTemporary(t *testing.T) {
+	tests := []struct {
+		status    FileStationStatus
+		temporary bool
+	}{
+		{WFM2_DB_FAIL, true},
+		{WFM2_PREPARE, true},
+		{WFM2_CLOUD_SERVER_ERROR, true},
+		{WFM2_PERMISSION_DENY, false},
+		{WFM2_FILE_NO_EXIST, false},
+		{WFM2_AUTH_FAIL, false},
+	}
+
+	for _, tt := range tests {
+		if tt.status.Temporary() != tt.temporary {
+			t.Errorf("expected Temporary() of %v to be %v", tt.status, tt.temporary)
+		}
+		if IsTemporaryError(newError("Test", "", nil, tt.status)) != tt.temporary {
+			t.Errorf("expected IsTemporaryError() of %v to be %v", tt.status, tt.temporary)
+		}
+	}
+}
+
+func TestStatusMessage(t *testing.T) {
+	if msg := WFM2_QUOTA_ERROR.Message(LanguageEnglish); msg != "You have reached the disk quota limit." {
+		t.Fatalf("wrong English message: %v", msg)
+	}
+	if msg := WFM2_QUOTA_ERROR.Message("zh_tw"); msg != "您的磁碟容量配額已滿" {
+		t.Fatalf("wrong Traditional Chinese message: %v", msg)
+	}
+	if msg := WFM2_NAME_DUP.Message(LanguageTraditionalChinese); msg != WFM2_NAME_DUP.Message(LanguageEnglish) {
+		t.Fatalf("expected English fallback: %v", msg)
+	}
+	if msg := FileStationStatus(999).Message(LanguageEnglish); msg != "WMF2_UNKNOWN:999" {
+		t.Fatalf("wrong unknown message: %v", msg)
+	}
+}
@@ -0,0 +1,59 @@
+package filestation
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// stubServer is a QNAP system, which accepts any login and answers
+// the calls of utilRequest.cgi by a handler. Only the latest session
+// is valid.
+type stubServer struct {
+	logins int32
+}
+
+// sessionID returns the ID of the latest session.
+func (s *stubServer) sessionID() string {
+	return fmt.Sprintf("sid-%v", atomic.LoadInt32(&s.logins))
+}
+
+// createStubSession connects to a new stub server. Responses are JSON,
+// unless the handler sets another content type.
+func createStubSession(t *testing.T, options *ConfigOptions, utilRequest http.HandlerFunc) (*FileStationSession, *stubServer) {
+	stub := &stubServer{}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		switch r.URL.Path {
+		case "/cgi-bin/filemanager/wfm2Login.cgi":
+			fmt.Fprintf(w, `{"status":1,"sid":"sid-%v","version":"4.3.6"}`, atomic.AddInt32(&stub.logins, 1))
+		case "/cgi-bin/filemanager/wfm2Logout.cgi":
+			w.Write([]byte(`{"status":1}`))
+		case "/cgi-bin/filemanager/utilRequest.cgi":
+			if r.URL.Query().Get("sid") != stub.sessionID() {
+				w.Write([]byte(`{"status":3}`)) // WFM2_AUTH_FAIL
+				return
+			}
+			utilRequest(w, r)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	if options == nil {
+		options = &ConfigOptions{APICallTimeout: 5 * time.Second}
+	}
+
+	s, err := Connect(server.URL, "admin", "admin", options)
+	if err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+
+	return s, stub
+}
@@ -2,7 +2,10 @@ package filestation
 
 import (
 	"encoding/base64"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"github.com/go-resty/resty/v2"
 )
 
 type loginResponse struct {
@@ -11,38 +14,200 @@ type loginResponse struct {
 	Build      string            `json:"build,omitempty"`
 	SessionID  string            `json:"sid,omitempty"`
 	AdminGroup int               `json:"admingroup,omitempty"`
+	QToken     string            `json:"qtoken,omitempty"`
 }
 
+// errLoginMethodRejected is returned by login() if the server
+// answers the credentials sent as form data by HTTP 405.
+var errLoginMethodRejected = errors.New("login method rejected")
+
 // Login perform the authentication against the QNAP storage.
 // Any existing session will be logged-out, first.
+//
+// The credentials are sent as form data, so they do not show up in
+// any access logs. Older firmware, which only accepts the credentials
+// as query parameters, is supported if enabled by ConfigOptions.LegacyGETLogin.
+//
+// The password is sent base64 encoded, unless specified otherwise by
+// ConfigOptions.PasswordEncoding. A persistent login token is requested,
+// which is used by Relogin(), if issued by the server (QTS 5.x).
 func (s *FileStationSession) Login(username, password string) error {
 	// make sure to close any existing sessions
 	s.Logout()
 
-	// perform login
-	var result loginResponse
+	return s.loginWithPassword("Login", username, password)
+}
 
-	res, err := s.conn.NewRequest().
-		ExpectContentType("application/json").
-		SetQueryParam("user", username).
-		SetQueryParam("pwd", encodePassword(password)).
-		SetResult(&result).
-		Get("cgi-bin/filemanager/wfm2Login.cgi")
-	if err != nil {
-		return fmt.Errorf("failed to perform request: %v", err)
+func (s *FileStationSession) loginWithPassword(op, username, password string) error {
+	encoding := s.options.PasswordEncoding
+
+	credentials := map[string]string{
+		"user":  username,
+		"pwd":   encodePasswordAs(password, encoding),
+		"remme": "1",
+	}
+
+	// the plain password must never be sent as query parameter
+	fallback := credentials
+	if encoding == PasswordEncodingPlain {
+		fallback = nil
+	}
+
+	err := s.performLogin(op, username, credentials, fallback)
+	if err == nil && s.options.RememberPassword {
+		s.mu.Lock()
+		s.password = password
+		s.mu.Unlock()
+	}
+
+	return err
+}
+
+// Relogin creates a new session for the user of the last Login().
+// The persistent login token (qtoken) is used if available,
+// see CapabilityQToken. Otherwise, the password is used again,
+// if enabled by ConfigOptions.RememberPassword.
+//
+// The login token is only sent as form data. If the server rejects it,
+// the password is used instead.
+//
+// The previous session is not logged out, as it might still be used
+// by concurrent requests. Expired sessions are renewed automatically.
+func (s *FileStationSession) Relogin() error {
+	s.renewMu.Lock()
+	defer s.renewMu.Unlock()
+
+	return s.relogin()
+}
+
+// renewSession renews an expired session, unless it has already been
+// renewed by another goroutine since the failed request has been sent.
+// A session, which has been logged-out, is never renewed.
+func (s *FileStationSession) renewSession(expiredSessionID string) error {
+	if expiredSessionID == "" {
+		return newError("Relogin", "", nil, errors.New("not logged-in"))
+	}
+
+	s.renewMu.Lock()
+	defer s.renewMu.Unlock()
+
+	if s.currentSessionID() != expiredSessionID {
+		return nil
+	}
+
+	return s.relogin()
+}
+
+func (s *FileStationSession) relogin() error {
+	s.mu.RLock()
+	username, password, qtoken := s.username, s.password, s.qtoken
+	s.mu.RUnlock()
+
+	if qtoken != "" {
+		credentials := map[string]string{
+			"user":   username,
+			"qtoken": qtoken,
+			"remme":  "1",
+		}
+
+		// the token is never sent as query parameter
+		err := s.performLogin("Relogin", username, credentials, nil)
+		if err == nil || password == "" {
+			return err
+		}
+	}
+
+	if username == "" {
+		return newError("Relogin", "", nil, errors.New("no previous login available"))
 	}
-	if res.StatusCode() != 200 {
-		return fmt.Errorf("failed to perform request: unexpected HTTP status code: %v", res.StatusCode())
+	if password == "" {
+		return newError("Relogin", "", nil, errors.New("no login token or password available"))
+	}
+
+	return s.loginWithPassword("Relogin", username, password)
+}
+
+// currentSessionID returns the ID of the current session, if logged-in.
+func (s *FileStationSession) currentSessionID() string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.sessionID
+}
+
+// performLogin sends the credentials as form data. The fallback credentials
+// are sent as query parameters, if the form login has been rejected or has
+// failed, and ConfigOptions.LegacyGETLogin is enabled.
+func (s *FileStationSession) performLogin(op, username string, credentials, fallback map[string]string) error {
+	postLogin := true
+	result, res, err := s.login(op, credentials, true)
+
+	rejected := err == errLoginMethodRejected || (err == nil && result.Status == WFM2_FAIL)
+	if rejected && fallback != nil && s.options.LegacyGETLogin {
+		postLogin = false
+		result, res, err = s.login(op, fallback, false)
+	}
+	if err == errLoginMethodRejected {
+		return newError(op, "", nil, fmt.Errorf("failed to perform request: %w, the server might require ConfigOptions.LegacyGETLogin", err))
+	}
+	if err != nil {
+		return err
 	}
 
 	switch result.Status {
 	case WFM2_SUCCESS: // success
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		s.serverInfo = ServerInfo{
+			Version:    result.Version,
+			Build:      result.Build,
+			AdminGroup: result.AdminGroup != 0,
+		}
+		s.postLogin = postLogin
+		s.username = username
+		if result.QToken != "" {
+			s.qtoken = result.QToken
+		}
 		s.sessionID = result.SessionID
-		s.conn.SetQueryParam("sid", s.sessionID)
 		return nil
 	}
 
-	return result.Status
+	return newError(op, "", res, result.Status)
+}
+
+func (s *FileStationSession) login(op string, credentials map[string]string, usePost bool) (*loginResponse, *resty.Response, error) {
+	var result loginResponse
+
+	if usePost {
+		res, err := s.conn.NewRequest().
+			SetFormData(credentials).
+			Post("cgi-bin/filemanager/wfm2Login.cgi")
+		if err != nil {
+			return nil, nil, newError(op, "", res, fmt.Errorf("failed to perform request: %v", err))
+		}
+		if res.StatusCode() == 405 {
+			return nil, nil, errLoginMethodRejected
+		}
+		if res.StatusCode() != 200 {
+			return nil, nil, newError(op, "", res, fmt.Errorf("failed to perform request: unexpected HTTP status code: %v", res.StatusCode()))
+		}
+		if err := json.Unmarshal(res.Body(), &result); err != nil {
+			return nil, nil, newError(op, "", res, fmt.Errorf("%w: %v", ErrNotJSON, err))
+		}
+
+		return &result, res, nil
+	}
+
+	res, err := s.execute(op, "", s.conn.NewRequest().
+		ExpectContentType("application/json").
+		SetQueryParams(credentials).
+		SetResult(&result), resty.MethodGet, "cgi-bin/filemanager/wfm2Login.cgi")
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return &result, res, nil
 }
 
 type logoutResponse struct {
@@ -51,36 +216,50 @@ type logoutResponse struct {
 	Build   string            `json:"build,omitempty"`
 }
 
-// Logout invalidates the session.
+// Logout invalidates the session. The login token and the password
+// are discarded, so the session is not renewed by later calls.
 func (s *FileStationSession) Logout() error {
+	s.mu.Lock()
+	sessionID := s.sessionID
+	s.username, s.password, s.qtoken = "", "", ""
+	s.mu.Unlock()
+
 	// no logged-in?
-	if s.sessionID == "" {
+	if sessionID == "" {
 		return nil
 	}
 
 	var result logoutResponse
 
-	res, err := s.conn.NewRequest().
+	res, err := s.execute("Logout", "", s.conn.NewRequest().
 		ExpectContentType("application/json").
-		SetResult(&result).
-		Get("cgi-bin/filemanager/wfm2Logout.cgi")
+		SetResult(&result), resty.MethodGet, "cgi-bin/filemanager/wfm2Logout.cgi")
 	if err != nil {
-		return fmt.Errorf("failed to perform request: %v", err)
-	}
-	if res.StatusCode() != 200 {
-		return fmt.Errorf("failed to perform request: unexpected HTTP status code: %v", res.StatusCode())
+		return err
 	}
 
 	switch result.Status {
 	case WFM2_SUCCESS: // success
-		s.sessionID = ""
-		s.conn.SetQueryParam("sid", "")
+		s.mu.Lock()
+		if s.sessionID == sessionID {
+			s.sessionID = ""
+		}
+		s.mu.Unlock()
 		return nil
 	}
 
-	return result.Status
+	return newError("Logout", "", res, result.Status)
 }
 
 func encodePassword(pwd string) string {
 	return base64.StdEncoding.EncodeToString([]byte(pwd))
 }
+
+func encodePasswordAs(pwd string, encoding PasswordEncoding) string {
+	switch encoding {
+	case PasswordEncodingPlain:
+		return pwd
+	}
+
+	return encodePassword(pwd)
+}
@@ -0,0 +1,21 @@
+package filestation
+
+import "testing"
+
+func TestPasswordEncode(t *testing.T) {
+	input := "admin"
+	expected := "YWRtaW4="
+
+	if encodePassword(input) != expected {
+		t.Fatal("password encoding failed")
+	}
+}
+
+func TestPasswordEncodeAs(t *testing.T) {
+	if encodePasswordAs("admin", PasswordEncodingBase64) != "YWRtaW4=" {
+		t.Fatal("base64 password encoding failed")
+	}
+	if encodePasswordAs("admin", PasswordEncodingPlain) != "admin" {
+		t.Fatal("plain password encoding failed")
+	}
+}
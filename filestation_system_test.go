@@ -1,42 +1,31 @@
-package filestation
+package filestation_test
 
 import (
+	"errors"
+	"github.com/nine-lives-later/go-qnap-filestation"
+	"github.com/nine-lives-later/go-qnap-filestation/filestationtest"
 	"os"
 	"strings"
 	"testing"
 )
 
-func createTestSession(t *testing.T) *FileStationSession {
+func createTestSession(t *testing.T) *filestation.FileStationSession {
 	return createTestSessionEx(t, "", "")
 }
 
-func createTestSessionEx(t *testing.T, username, password string) *FileStationSession {
+func createTestSessionEx(t *testing.T, username, password string) *filestation.FileStationSession {
 	// retrieve api auth if undefined
-	if username == "" {
-		username = os.Getenv("QNAP_USER")
+	defaultUsername, defaultPassword := testCredentials()
 
-		if username == "" {
-			username = "unittest-user"
-		}
+	if username == "" {
+		username = defaultUsername
 	}
-
 	if password == "" {
-		password = os.Getenv("QNAP_PWD")
-
-		if password == "" {
-			password = "t3st123!!!"
-		}
-	}
-
-	// retrieve hostname
-	host := os.Getenv("QNAP_HOSTNAME")
-
-	if host == "" {
-		host = "192.168.211.110:443"
+		password = defaultPassword
 	}
 
 	// create the session
-	session, err := Connect(host, username, password, nil)
+	session, err := filestation.Connect(testHost(t), username, password, nil)
 
 	if err != nil {
 		t.Fatalf("Failed to connect to QNAP File Station API: %v", err)
@@ -45,17 +34,40 @@ func createTestSessionEx(t *testing.T, username, password string) *FileStationSe
 	return session
 }
 
-func TestPasswordEncode(t *testing.T) {
-	input := "admin"
-	expected := "YWRtaW4="
+func testCredentials() (username, password string) {
+	username = os.Getenv("QNAP_USER")
+	if username == "" {
+		username = "unittest-user"
+	}
+
+	password = os.Getenv("QNAP_PWD")
+	if password == "" {
+		password = "t3st123!!!"
+	}
+
+	return username, password
+}
+
+// testHost returns the QNAP system to test against. Unless specified
+// by QNAP_HOSTNAME, a fake File Station is started.
+func testHost(t *testing.T) string {
+	if host := os.Getenv("QNAP_HOSTNAME"); host != "" {
+		return host
+	}
+
+	srv := filestationtest.NewServer(t.TempDir())
+	t.Cleanup(srv.Close)
 
-	if encodePassword(input) != expected {
-		t.Fatal("password encoding failed")
+	srv.AddUser(testCredentials())
+	if err := srv.AddShare("unittest", true); err != nil {
+		t.Fatalf("Failed to create share: %v", err)
 	}
+
+	return srv.URL
 }
 
 func TestInvalidHost(t *testing.T) {
-	_, err := Connect("d0esn0tex1st", "inva1dus3r", "inval1dAp1K3y", nil)
+	_, err := filestation.Connect("d0esn0tex1st", "inva1dus3r", "inval1dAp1K3y", nil)
 	if err == nil {
 		t.Fatal("Error expected")
 	}
@@ -68,6 +80,10 @@ func TestInvalidHost(t *testing.T) {
 func TestConnect(t *testing.T) {
 	s := createTestSession(t)
 
+	if s.ServerInfo().Version == "" {
+		t.Fatal("Expected server version to be known")
+	}
+
 	// real logout
 	err := s.Logout()
 	if err != nil {
@@ -82,16 +98,11 @@ func TestConnect(t *testing.T) {
 }
 
 func TestConnect_InvalidLogin(t *testing.T) {
-	host := os.Getenv("QNAP_HOSTNAME")
-	if host == "" {
-		host = "192.168.211.110:443"
-	}
-
-	_, err := Connect(host, "unkn0wnUs3r", "!nval1dP@ssw0rd", nil)
+	_, err := filestation.Connect(testHost(t), "unkn0wnUs3r", "!nval1dP@ssw0rd", nil)
 	if err == nil {
 		t.Fatal("Error expected")
 	}
-	if err != WFM2_FAIL {
+	if !errors.Is(err, filestation.WFM2_FAIL) {
 		t.Fatalf("Wrong error message returned: %v", err)
 	}
 }
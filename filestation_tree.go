@@ -0,0 +1,117 @@
+package filestation
+
+import (
+	"context"
+	"github.com/go-resty/resty/v2"
+)
+
+// shareRootNode is the get_tree node of the shares.
+const shareRootNode = "share_root"
+
+// FolderTreeNode is a folder and its sub-folders, as returned by GetFolderTree().
+type FolderTreeNode struct {
+	FolderListEntry
+
+	// Children contains the sub-folders, if Loaded is set.
+	Children []*FolderTreeNode
+
+	// Loaded indicates that the sub-folders have been retrieved.
+	// It is not set for the folders beyond the requested depth.
+	Loaded bool
+
+	// Truncated indicates that the folder contains more items than the
+	// server returns (see MaxItemLimit and ItemCount), so Children is incomplete.
+	Truncated bool
+}
+
+// GetFolderTree retrieves the folders below the path up to the given depth.
+// A depth of 1 only retrieves the direct sub-folders, which is suitable
+// for lazy-loading folder pickers. The path "/" retrieves the shares.
+func (s *FileStationSession) GetFolderTree(ctx context.Context, path string, depth int) (*FolderTreeNode, error) {
+	return getFolderTree(ctx, path, depth, func(ctx context.Context, path, node string) ([]FolderListEntry, error) {
+		return s.getTree(ctx, "GetFolderTree", path, node)
+	})
+}
+
+// treeLister retrieves the sub-folders of a get_tree node.
+type treeLister func(ctx context.Context, path, node string) ([]FolderListEntry, error)
+
+func getFolderTree(ctx context.Context, path string, depth int, list treeLister) (*FolderTreeNode, error) {
+	node := shareRootNode
+	if path != "/" {
+		var err error
+		path, err = checkPath("GetFolderTree", path)
+		if err != nil {
+			return nil, err
+		}
+		node = path
+	}
+
+	root := newFolderTree(path)
+	if err := loadFolderTree(ctx, root, node, depth, list); err != nil {
+		return nil, err
+	}
+
+	return root, nil
+}
+
+func newFolderTree(path string) *FolderTreeNode {
+	_, name := splitPath(path)
+
+	return &FolderTreeNode{
+		FolderListEntry: FolderListEntry{
+			Path: path,
+			Text: name,
+		},
+	}
+}
+
+func loadFolderTree(ctx context.Context, parent *FolderTreeNode, node string, depth int, list treeLister) error {
+	if depth <= 0 {
+		return nil
+	}
+
+	entries, err := list(ctx, parent.Path, node)
+	if err != nil {
+		return err
+	}
+
+	parent.Loaded = true
+	parent.Children = make([]*FolderTreeNode, 0, len(entries))
+
+	for _, e := range entries {
+		child := &FolderTreeNode{FolderListEntry: e}
+		child.Truncated = e.MaxItemLimit > 0 && e.ItemCount > e.MaxItemLimit
+
+		parent.Children = append(parent.Children, child)
+	}
+
+	if parent.MaxItemLimit > 0 && len(entries) >= parent.MaxItemLimit {
+		parent.Truncated = true
+	}
+
+	for _, child := range parent.Children {
+		if err := loadFolderTree(ctx, child, child.Path, depth-1, list); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// getTree retrieves the sub-folders of a node.
+func (s *FileStationSession) getTree(ctx context.Context, op, path, node string) ([]FolderListEntry, error) {
+	var result []FolderListEntry
+
+	_, err := s.execute(op, path, s.conn.NewRequest().
+		SetContext(ctx).
+		ExpectContentType("application/json").
+		SetQueryParam("func", "get_tree").
+		SetQueryParam("node", node).
+		SetResult(&result), resty.MethodGet, "cgi-bin/filemanager/utilRequest.cgi")
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
@@ -0,0 +1,95 @@
+package filestation
+
+import (
+	"context"
+	"net/http"
+	"testing"
+)
+
+func createTreeTestSession(t *testing.T, nodes *[]string) *FileStationSession {
+	trees := map[string]string{
+		"share_root":       `[{"id":"/share","text":"share"}]`,
+		"/share":           `[{"id":"/share/a","text":"a","max_item_limit":2,"real_total":5},{"id":"/share/b","text":"b"}]`,
+		"/share/a":         `[{"id":"/share/a/x","text":"x"},{"id":"/share/a/y","text":"y"}]`,
+		"/share/b":         `[]`,
+		"/share/a/x":       `[]`,
+		"/share/a/y":       `[]`,
+		"/share/not-found": `{"status":5}`, // WFM2_FILE_NO_EXIST
+	}
+
+	s, _ := createStubSession(t, nil, func(w http.ResponseWriter, r *http.Request) {
+		node := r.URL.Query().Get("node")
+		*nodes = append(*nodes, node)
+		w.Write([]byte(trees[node]))
+	})
+
+	return s
+}
+
+func TestGetFolderTree(t *testing.T) {
+	var nodes []string
+	s := createTreeTestSession(t, &nodes)
+
+	tree, err := s.GetFolderTree(context.Background(), "/share/", 1)
+	if err != nil {
+		t.Fatalf("Failed to get folder tree: %v", err)
+	}
+	if tree.Path != "/share" || tree.Text != "share" || !tree.Loaded || len(tree.Children) != 2 {
+		t.Fatalf("Wrong root node: %+v", tree)
+	}
+
+	a := tree.Children[0]
+	if a.Path != "/share/a" || !a.Truncated || a.Loaded || a.Children != nil {
+		t.Fatalf("Wrong child node: %+v", a)
+	}
+	if len(nodes) != 1 {
+		t.Fatalf("Expected a single request, got %v", nodes)
+	}
+
+	tree, err = s.GetFolderTree(context.Background(), "/share", 3)
+	if err != nil {
+		t.Fatalf("Failed to get folder tree: %v", err)
+	}
+	a = tree.Children[0]
+	if !a.Loaded || len(a.Children) != 2 || a.Children[1].Path != "/share/a/y" || !a.Children[1].Loaded {
+		t.Fatalf("Wrong nested nodes: %+v", a)
+	}
+	if tree.Children[1].Truncated {
+		t.Fatal("Expected folder without item limit not to be truncated")
+	}
+}
+
+func TestGetFolderTreeShares(t *testing.T) {
+	var nodes []string
+	s := createTreeTestSession(t, &nodes)
+
+	tree, err := s.GetFolderTree(context.Background(), "/", 1)
+	if err != nil {
+		t.Fatalf("Failed to get folder tree: %v", err)
+	}
+	if len(tree.Children) != 1 || tree.Children[0].Path != "/share" {
+		t.Fatalf("Wrong shares: %+v", tree.Children)
+	}
+	if len(nodes) != 1 || nodes[0] != "share_root" {
+		t.Fatalf("Wrong node requested: %v", nodes)
+	}
+}
+
+func TestGetFolderTreeErrors(t *testing.T) {
+	var nodes []string
+	s := createTreeTestSession(t, &nodes)
+
+	if _, err := s.GetFolderTree(context.Background(), "share", 1); err == nil {
+		t.Fatal("Expected invalid path to fail")
+	}
+
+	_, err := s.GetFolderTree(context.Background(), "/share/not-found", 1)
+	if !isNotExist(err) {
+		t.Fatalf("Expected not exist error, got %v", err)
+	}
+
+	tree, err := s.GetFolderTree(context.Background(), "/share", 0)
+	if err != nil || tree.Loaded || len(nodes) != 1 {
+		t.Fatalf("Expected no request for depth 0: %v %v", err, nodes)
+	}
+}
@@ -1,8 +1,13 @@
 package filestation
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
-	"path/filepath"
+	"github.com/go-resty/resty/v2"
+	"io"
+	"io/fs"
 	"strconv"
 	"strings"
 )
@@ -24,24 +29,11 @@ type FolderListEntry struct {
 
 // GetShareList retrieves the list of shares.
 func (s *FileStationSession) GetShareList() ([]FolderListEntry, error) {
-	var result []FolderListEntry
-
-	res, err := s.conn.NewRequest().
-		ExpectContentType("application/json").
-		SetQueryParam("func", "get_tree").
-		SetQueryParam("node", "share_root").
-		SetResult(&result).
-		Get("cgi-bin/filemanager/utilRequest.cgi")
-	if err != nil {
-		return nil, fmt.Errorf("failed to perform request: %v", err)
-	}
-	if res.StatusCode() != 200 {
-		return nil, fmt.Errorf("failed to perform request: unexpected HTTP status code: %v", res.StatusCode())
-	}
-
-	return result, nil
+	return s.getTree(context.Background(), "GetShareList", "", shareRootNode)
 }
 
+// FileListEntry is a file or folder as reported by the QNAP system.
+// It does not implement fs.FileInfo, use Info() instead.
 type FileListEntry struct {
 	Name              string `json:"filename,omitempty"`
 	FullPath          string
@@ -61,77 +53,119 @@ type FileListEntry struct {
 }
 
 type getFileListResponse struct {
-	ItemCount       int             `json:"real_total,omitempty"`
-	ACL             int             `json:"acl,omitempty"`
-	IsACLEnabled    int             `json:"is_acl_enable,omitempty"`
-	IsWinACLEnabled int             `json:"is_winacl_enable,omitempty"`
-	Entries         []FileListEntry `json:"datas,omitempty"`
+	Status          FileStationStatus `json:"status,omitempty"`
+	ItemCount       int               `json:"real_total,omitempty"`
+	ACL             int               `json:"acl,omitempty"`
+	IsACLEnabled    int               `json:"is_acl_enable,omitempty"`
+	IsWinACLEnabled int               `json:"is_winacl_enable,omitempty"`
+	Entries         []FileListEntry   `json:"datas,omitempty"`
 }
 
 // GetFileList retrieves the list of files and folders of a share.
 func (s *FileStationSession) GetFileList(path string) ([]FileListEntry, error) {
-	return s.getFileListInternal(path, 1000)
+	return s.getFileListInternal(context.Background(), path, defaultPageSize)
+}
+
+// GetFileListWithOptions retrieves the list of files and folders of a share,
+// sorted and filtered as specified by the options.
+func (s *FileStationSession) GetFileListWithOptions(path string, opts ListOptions) ([]FileListEntry, error) {
+	return s.getFileListWithOptions(context.Background(), path, opts)
 }
 
-func (s *FileStationSession) getFileListInternal(path string, limit int) ([]FileListEntry, error) {
+func (s *FileStationSession) getFileListInternal(ctx context.Context, path string, limit int) ([]FileListEntry, error) {
+	return s.getFileListWithOptions(ctx, path, ListOptions{PageSize: limit})
+}
+
+func (s *FileStationSession) getFileListWithOptions(ctx context.Context, path string, opts ListOptions) ([]FileListEntry, error) {
+	return listAll(s.ListIter(ctx, path, opts))
+}
+
+// listAll collects the remaining entries of the iterator.
+func listAll(it *ListIterator) ([]FileListEntry, error) {
 	ret := make([]FileListEntry, 0)
 
-	for true {
-		var result getFileListResponse
-
-		res, err := s.conn.NewRequest().
-			ExpectContentType("application/json").
-			SetQueryParam("func", "get_list").
-			SetQueryParam("path", path).
-			SetQueryParam("list_mode", "all").
-			SetQueryParam("dir", "ASC").
-			SetQueryParam("limit", strconv.Itoa(limit)).
-			SetQueryParam("start", strconv.Itoa(len(ret))).
-			SetResult(&result).
-			Get("cgi-bin/filemanager/utilRequest.cgi")
-		if err != nil {
-			return nil, fmt.Errorf("failed to perform request: %v", err)
-		}
-		if res.StatusCode() != 200 {
-			return nil, fmt.Errorf("failed to perform request: unexpected HTTP status code: %v", res.StatusCode())
-		}
+	for it.Next() {
+		ret = append(ret, *it.Entry())
+	}
+	if err := it.Err(); err != nil {
+		return nil, err
+	}
 
-		// copy entries
-		ret = append(ret, result.Entries...)
+	return ret, nil
+}
 
-		// reached last entry?
-		if len(result.Entries) < limit {
-			break
+// getFileListPage retrieves a single page of the list of files and folders.
+func (s *FileStationSession) getFileListPage(ctx context.Context, path string, start, limit int, opts *ListOptions) ([]FileListEntry, error) {
+	var result getFileListResponse
+
+	req := s.conn.NewRequest().
+		SetContext(ctx).
+		ExpectContentType("application/json").
+		SetQueryParam("func", "get_list").
+		SetQueryParam("path", path).
+		SetQueryParam("list_mode", "all").
+		SetQueryParam("limit", strconv.Itoa(limit)).
+		SetQueryParam("start", strconv.Itoa(start)).
+		SetResult(&result)
+
+	// the order is only requested, if set by the caller
+	sort := opts.SortBy.param()
+	if sort == "" && opts.Descending {
+		sort = SortField_Name.param()
+	}
+	if sort != "" {
+		dir := "ASC"
+		if opts.Descending {
+			dir = "DESC"
 		}
+		req.SetQueryParam("sort", sort).SetQueryParam("dir", dir)
+	}
+
+	if opts.NameFilter != "" {
+		req.SetQueryParam("filename", opts.NameFilter)
+	}
+
+	res, err := s.execute("GetFileList", path, req, resty.MethodGet, "cgi-bin/filemanager/utilRequest.cgi")
+	if err != nil {
+		return nil, err
+	}
+
+	// listings do not contain a status, unless failed
+	switch result.Status {
+	case WFM2_FAIL, WFM2_SUCCESS:
+	default:
+		return nil, newError("GetFileList", path, res, result.Status)
 	}
 
 	// inject full path
-	for i := range ret {
-		e := &ret[i]
+	for i := range result.Entries {
+		e := &result.Entries[i]
 
-		e.FullPath = filepath.ToSlash(filepath.Join(path, e.Name))
+		e.FullPath = joinPath(path, e.Name)
 	}
 
-	return ret, nil
+	return result.Entries, nil
 }
 
 // GetFileStat checks if a file or folder exists.
 func (s *FileStationSession) GetFileStat(path string) (*FileListEntry, error) {
+	path, err := checkPath("GetFileStat", path)
+	if err != nil {
+		return nil, err
+	}
+
 	var result getFileListResponse
+	dir, name := splitPath(path)
 
-	res, err := s.conn.NewRequest().
+	_, err = s.execute("GetFileStat", path, s.conn.NewRequest().
 		ExpectContentType("application/json").
 		SetQueryParam("func", "stat").
-		SetQueryParam("path", filepath.ToSlash(filepath.Dir(path))).
-		SetQueryParam("file_name", filepath.Base(path)).
+		SetQueryParam("path", dir).
+		SetQueryParam("file_name", name).
 		SetQueryParam("file_total", "1").
-		SetResult(&result).
-		Get("cgi-bin/filemanager/utilRequest.cgi")
+		SetResult(&result), resty.MethodGet, "cgi-bin/filemanager/utilRequest.cgi")
 	if err != nil {
-		return nil, fmt.Errorf("failed to perform request: %v", err)
-	}
-	if res.StatusCode() != 200 {
-		return nil, fmt.Errorf("failed to perform request: unexpected HTTP status code: %v", res.StatusCode())
+		return nil, err
 	}
 
 	if len(result.Entries) <= 0 {
@@ -146,7 +180,7 @@ func (s *FileStationSession) GetFileStat(path string) (*FileListEntry, error) {
 	}
 
 	// inject full path
-	entry.FullPath = filepath.ToSlash(path)
+	entry.FullPath = path
 
 	return entry, nil
 }
@@ -154,10 +188,16 @@ func (s *FileStationSession) GetFileStat(path string) (*FileListEntry, error) {
 // SetPrivilege changes file-system level permissions
 // of a file or folder (chmod).
 func (s *FileStationSession) SetPrivilege(path string, privilege Privilege, recursive bool) error {
+	path, err := checkPath("SetPrivilege", path)
+	if err != nil {
+		return err
+	}
+
 	var result genericStatusResponse
+	dir, name := splitPath(path)
 	pbits := privilege.Bits()
 
-	res, err := s.conn.NewRequest().
+	res, err := s.execute("SetPrivilege", path, s.conn.NewRequest().
 		ExpectContentType("application/json").
 		SetQueryParam("func", "set_privilege").
 		SetFormData(map[string]string{
@@ -171,17 +211,13 @@ func (s *FileStationSession) SetPrivilege(path string, privilege Privilege, recu
 			"bOther_r":     boolToIntStr(pbits.OtherRead),
 			"bOther_w":     boolToIntStr(pbits.OtherWrite),
 			"bOther_x":     boolToIntStr(pbits.OtherExecute),
-			"source_path":  filepath.ToSlash(filepath.Dir(path)),
-			"source_file":  filepath.Base(path),
+			"source_path":  dir,
+			"source_file":  name,
 			"source_total": "1",
 		}).
-		SetResult(&result).
-		Post("cgi-bin/filemanager/utilRequest.cgi")
+		SetResult(&result), resty.MethodPost, "cgi-bin/filemanager/utilRequest.cgi")
 	if err != nil {
-		return fmt.Errorf("failed to perform request: %v", err)
-	}
-	if res.StatusCode() != 200 {
-		return fmt.Errorf("failed to perform request: unexpected HTTP status code: %v", res.StatusCode())
+		return err
 	}
 
 	switch result.Status {
@@ -189,26 +225,32 @@ func (s *FileStationSession) SetPrivilege(path string, privilege Privilege, recu
 		return nil
 	}
 
-	return result.Status
+	return newError("SetPrivilege", path, res, result.Status)
 }
 
 // CreateFolder creates a new folder.
 // The base directory must exists.
 func (s *FileStationSession) CreateFolder(path string) (bool, error) {
+	path, err := checkPath("CreateFolder", path)
+	if err != nil {
+		return false, err
+	}
+
 	var result genericStatusResponse
+	dir, name := splitPath(path)
 
-	res, err := s.conn.NewRequest().
+	if err := ValidateName(name); err != nil {
+		return false, newError("CreateFolder", path, nil, err)
+	}
+
+	res, err := s.execute("CreateFolder", path, s.conn.NewRequest().
 		ExpectContentType("application/json").
 		SetQueryParam("func", "createdir").
-		SetQueryParam("dest_path", filepath.ToSlash(filepath.Dir(path))).
-		SetQueryParam("dest_folder", filepath.Base(path)).
-		SetResult(&result).
-		Get("cgi-bin/filemanager/utilRequest.cgi")
+		SetQueryParam("dest_path", dir).
+		SetQueryParam("dest_folder", name).
+		SetResult(&result), resty.MethodGet, "cgi-bin/filemanager/utilRequest.cgi")
 	if err != nil {
-		return false, fmt.Errorf("failed to perform request: %v", err)
-	}
-	if res.StatusCode() != 200 {
-		return false, fmt.Errorf("failed to perform request: unexpected HTTP status code: %v", res.StatusCode())
+		return false, err
 	}
 
 	switch result.Status {
@@ -218,37 +260,44 @@ func (s *FileStationSession) CreateFolder(path string) (bool, error) {
 		return false, nil
 	}
 
-	return false, result.Status
+	return false, newError("CreateFolder", path, res, result.Status)
 }
 
 // EnsureFolder creates a new folder and its parent directories.
 func (s *FileStationSession) EnsureFolder(path string) (int, error) {
-	if !strings.HasPrefix(path, "/") {
-		return 0, fmt.Errorf("path does not begin with a slash: %v", path)
+	return ensureFolder(s, path)
+}
+
+// ensureFolder creates a new folder and its parent directories
+// by using the basic operations of the file station.
+func ensureFolder(s FileStation, path string) (int, error) {
+	path, err := checkPath("EnsureFolder", path)
+	if err != nil {
+		return 0, err
 	}
 
 	// already exists?
 	exists, err := s.GetFileStat(path)
 	if err != nil {
-		return 0, fmt.Errorf("failed to check for folder '%v': %v", path, err)
+		return 0, err
 	}
 	if exists != nil {
 		return 0, nil
 	}
 
 	// create sub-folders
-	parts := strings.Split(filepath.ToSlash(path), "/")[1:]
+	parts := strings.Split(path, "/")[1:]
 	if len(parts) < 2 {
-		return 0, fmt.Errorf("path is not a subfolder of a share: %v", path)
+		return 0, newError("EnsureFolder", path, nil, errors.New("path is not a subfolder of a share"))
 	}
 
 	createdOverall := 0
 	for i := 1; i < len(parts); i++ {
-		subPath := "/" + filepath.ToSlash(filepath.Join(parts[0:i+1]...))
+		subPath := "/" + strings.Join(parts[0:i+1], "/")
 
 		created, err := s.CreateFolder(subPath)
 		if err != nil {
-			return createdOverall, fmt.Errorf("failed to create sub-folder '%v': %v", subPath, err)
+			return createdOverall, err
 		}
 
 		if created {
@@ -261,44 +310,345 @@ func (s *FileStationSession) EnsureFolder(path string) (int, error) {
 
 // DeleteFile deletes a file or folder.
 func (s *FileStationSession) DeleteFile(path string) (bool, error) {
+	path, err := checkPath("DeleteFile", path)
+	if err != nil {
+		return false, err
+	}
+
 	return s.deleteFileInternal(path, false)
 }
 
 // DeleteFileNoRecycleBin deletes a file or folder without moving them to the recycling bin.
 func (s *FileStationSession) DeleteFileNoRecycleBin(path string) (bool, error) {
+	path, err := checkPath("DeleteFile", path)
+	if err != nil {
+		return false, err
+	}
+
 	return s.deleteFileInternal(path, true)
 }
 
 func (s *FileStationSession) deleteFileInternal(path string, force bool) (bool, error) {
-	forceStr := "0"
-	if force {
-		forceStr = "1"
+	status, res, err := s.deleteRequest("DeleteFile", path, force)
+	if err != nil {
+		return false, err
+	}
+
+	switch status {
+	case WFM2_SUCCESS: // success
+		return true, nil
+	case WFM2_FAIL, WFM2_PERMISSION_DENY: // file not found
+		return false, nil
+	}
+
+	return false, newError("DeleteFile", path, res, status)
+}
+
+// DeleteResult is the outcome of Delete().
+type DeleteResult int
+
+const (
+	DeleteResult_Deleted           DeleteResult = iota + 1 // deleted permanently
+	DeleteResult_MovedToRecycleBin                         // moved to the recycle bin of the share
+	DeleteResult_NotFound                                  // file or folder does not exist
+	DeleteResult_PermissionDenied                          // deleting is not permitted
+)
+
+func (r DeleteResult) String() string {
+	switch r {
+	case DeleteResult_Deleted:
+		return "Deleted"
+	case DeleteResult_MovedToRecycleBin:
+		return "MovedToRecycleBin"
+	case DeleteResult_NotFound:
+		return "NotFound"
+	case DeleteResult_PermissionDenied:
+		return "PermissionDenied"
+	}
+
+	return fmt.Sprintf("DeleteResult(%v)", int(r))
+}
+
+// Delete deletes a file or folder and reports the outcome.
+// Unlike DeleteFile(), a missing file and a denied permission are
+// distinguished by checking the existence of the file, first.
+// The recycle bin settings of the shares are retrieved on the first
+// deletion into a recycle bin and cached for the session.
+func (s *FileStationSession) Delete(path string, noRecycleBin bool) (DeleteResult, error) {
+	path, err := checkPath("Delete", path)
+	if err != nil {
+		return 0, err
+	}
+
+	// already gone?
+	stat, err := s.GetFileStat(path)
+	if err != nil {
+		return 0, err
+	}
+	if stat == nil {
+		return DeleteResult_NotFound, nil
+	}
+
+	// perform delete
+	status, res, err := s.deleteRequest("Delete", path, noRecycleBin)
+	if err != nil {
+		if errors.Is(err, fs.ErrPermission) {
+			return DeleteResult_PermissionDenied, nil
+		}
+		return 0, err
+	}
+
+	switch status {
+	case WFM2_SUCCESS: // success
+		if noRecycleBin {
+			return DeleteResult_Deleted, nil
+		}
+
+		recycled, err := s.hasRecycleBin(path)
+		if err != nil {
+			return 0, err
+		}
+		if recycled {
+			return DeleteResult_MovedToRecycleBin, nil
+		}
+		return DeleteResult_Deleted, nil
+	case WFM2_PERMISSION_DENY, WFM2_SRC_PERMISSION_DENY:
+		return DeleteResult_PermissionDenied, nil
+	case WFM2_FILE_NO_EXIST: // removed in the meantime
+		return DeleteResult_NotFound, nil
 	}
 
+	return 0, newError("Delete", path, res, status)
+}
+
+func (s *FileStationSession) deleteRequest(op, path string, force bool) (FileStationStatus, *resty.Response, error) {
 	var result genericStatusResponse
+	dir, name := splitPath(path)
 
-	res, err := s.conn.NewRequest().
+	res, err := s.execute(op, path, s.conn.NewRequest().
 		ExpectContentType("application/json").
 		SetQueryParam("func", "delete").
-		SetQueryParam("path", filepath.ToSlash(filepath.Dir(path))).
-		SetQueryParam("file_name", filepath.Base(path)).
+		SetQueryParam("path", dir).
+		SetQueryParam("file_name", name).
 		SetQueryParam("file_total", "1").
-		SetQueryParam("force", forceStr).
-		SetResult(&result).
-		Get("cgi-bin/filemanager/utilRequest.cgi")
+		SetQueryParam("force", boolToIntStr(force)).
+		SetResult(&result), resty.MethodGet, "cgi-bin/filemanager/utilRequest.cgi")
+	if err != nil {
+		return 0, nil, err
+	}
+
+	return result.Status, res, nil
+}
+
+// hasRecycleBin checks if the share of the path has its recycle bin enabled.
+// The settings of all shares are retrieved once and cached for the session.
+func (s *FileStationSession) hasRecycleBin(path string) (bool, error) {
+	shareName, err := ShareOf(path)
+	if err != nil {
+		return false, nil
+	}
+
+	s.mu.RLock()
+	recycleBin, ok := s.recycleBins[shareName]
+	s.mu.RUnlock()
+
+	if ok {
+		return recycleBin, nil
+	}
+
+	shares, err := s.GetShareList()
+	if err != nil {
+		return false, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.recycleBins = make(map[string]bool, len(shares))
+	for _, share := range shares {
+		s.recycleBins[share.Path] = share.RecycleBin == "1"
+	}
+
+	return s.recycleBins[shareName], nil
+}
+
+// Download opens a file for reading its content.
+// The caller must close the returned stream.
+//
+// Like other calls, the request is retried and repeated after renewing an
+// expired session, until the content is being received.
+func (s *FileStationSession) Download(path string) (io.ReadCloser, error) {
+	path, err := checkPath("Download", path)
+	if err != nil {
+		return nil, err
+	}
+
+	dir, name := splitPath(path)
+
+	req := s.conn.NewRequest().
+		SetDoNotParseResponse(true).
+		SetQueryParam("func", "download").
+		SetQueryParam("isfolder", "0").
+		SetQueryParam("compress", "0").
+		SetQueryParam("source_path", dir).
+		SetQueryParam("source_file", name).
+		SetQueryParam("source_total", "1")
+
+	url := "cgi-bin/filemanager/utilRequest.cgi"
+	res, err := s.executeWith("Download", path, req, url, rewinder(nil), func() (*resty.Response, error) {
+		return s.downloadOnce(path, req, url)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return res.RawBody(), nil
+}
+
+// downloadOnce performs a download request without parsing the response,
+// so the content can be streamed. Errors are reported as JSON, instead.
+func (s *FileStationSession) downloadOnce(path string, req *resty.Request, url string) (*resty.Response, error) {
+	res, err := req.Get(url)
 	if err != nil {
-		return false, fmt.Errorf("failed to perform request: %v", err)
+		return nil, newError("Download", path, res, fmt.Errorf("failed to perform request: %w", err))
 	}
+
+	// errors are reported as JSON, files as attachment
+	if res.StatusCode() == 200 && (res.Header().Get("Content-Disposition") != "" || !strings.Contains(res.Header().Get("Content-Type"), "json")) {
+		return res, nil
+	}
+
+	body := res.RawBody()
+	defer body.Close()
+
+	data, _ := io.ReadAll(io.LimitReader(body, maxErrorBodyLength+1))
+
+	var e *Error
 	if res.StatusCode() != 200 {
-		return false, fmt.Errorf("failed to perform request: unexpected HTTP status code: %v", res.StatusCode())
+		e = newError("Download", path, res, fmt.Errorf("failed to perform request: unexpected HTTP status code: %v", res.StatusCode()))
+	} else {
+		var result genericStatusResponse
+		if err := json.Unmarshal(data, &result); err != nil {
+			e = newError("Download", path, res, fmt.Errorf("%w: %v", ErrNotJSON, err))
+		} else {
+			if result.Status == WFM2_SUCCESS {
+				result.Status = WFM2_FAIL
+			}
+			e = newError("Download", path, res, result.Status)
+		}
+	}
+
+	// the body has not been parsed by resty
+	e.Body = truncateBody(data)
+
+	return nil, e
+}
+
+// Upload creates or replaces a file with the content of the reader.
+// The parent folder must exist.
+//
+// The upload is only retried, or repeated after renewing an expired
+// session, if the content implements io.Seeker (e.g. *os.File).
+func (s *FileStationSession) Upload(path string, content io.Reader, overwrite bool) error {
+	path, err := checkPath("Upload", path)
+	if err != nil {
+		return err
+	}
+
+	var result genericStatusResponse
+	dir, name := splitPath(path)
+
+	if err := ValidateName(name); err != nil {
+		return newError("Upload", path, nil, err)
+	}
+
+	res, err := s.executeStream("Upload", path, s.conn.NewRequest().
+		ExpectContentType("application/json").
+		SetQueryParam("func", "upload").
+		SetQueryParam("type", "standard").
+		SetQueryParam("dest_path", dir).
+		SetQueryParam("overwrite", boolToIntStr(overwrite)).
+		SetQueryParam("progress", strings.Replace(dir+"/"+name, "/", "-", -1)).
+		SetFileReader("file", name, content).
+		SetResult(&result), resty.MethodPost, "cgi-bin/filemanager/utilRequest.cgi", content)
+	if err != nil {
+		return err
 	}
 
 	switch result.Status {
 	case WFM2_SUCCESS: // success
-		return true, nil
-	case WFM2_FAIL, WFM2_PERMISSION_DENY: // file not found
-		return false, nil
+		return nil
+	}
+
+	return newError("Upload", path, res, result.Status)
+}
+
+// Rename changes the name of a file or folder.
+// The file or folder stays in its parent folder.
+func (s *FileStationSession) Rename(path, newName string) error {
+	path, err := checkPath("Rename", path)
+	if err != nil {
+		return err
+	}
+
+	var result genericStatusResponse
+	dir, name := splitPath(path)
+
+	if err := ValidateName(newName); err != nil {
+		return newError("Rename", path, nil, err)
+	}
+
+	res, err := s.execute("Rename", path, s.conn.NewRequest().
+		ExpectContentType("application/json").
+		SetQueryParam("func", "rename").
+		SetQueryParam("path", dir).
+		SetQueryParam("source_name", name).
+		SetQueryParam("dest_name", newName).
+		SetResult(&result), resty.MethodGet, "cgi-bin/filemanager/utilRequest.cgi")
+	if err != nil {
+		return err
+	}
+
+	switch result.Status {
+	case WFM2_SUCCESS: // success
+		return nil
+	}
+
+	return newError("Rename", path, res, result.Status)
+}
+
+// Move moves a file or folder into another folder.
+// Existing files are not overwritten.
+func (s *FileStationSession) Move(path, destFolder string) error {
+	path, err := checkPath("Move", path)
+	if err != nil {
+		return err
+	}
+	destFolder, err = checkPath("Move", destFolder)
+	if err != nil {
+		return err
+	}
+
+	var result genericStatusResponse
+	dir, name := splitPath(path)
+
+	res, err := s.execute("Move", path, s.conn.NewRequest().
+		ExpectContentType("application/json").
+		SetQueryParam("func", "move").
+		SetQueryParam("source_path", dir).
+		SetQueryParam("source_file", name).
+		SetQueryParam("source_total", "1").
+		SetQueryParam("dest_path", destFolder).
+		SetQueryParam("mode", "1").
+		SetResult(&result), resty.MethodGet, "cgi-bin/filemanager/utilRequest.cgi")
+	if err != nil {
+		return err
+	}
+
+	switch result.Status {
+	case WFM2_SUCCESS: // success
+		return nil
 	}
 
-	return false, result.Status
+	return newError("Move", path, res, result.Status)
 }
@@ -0,0 +1,259 @@
+package filestation
+
+import (
+	"context"
+	"io/fs"
+	"path"
+	"sort"
+	"sync"
+)
+
+// WalkFunc is called by Walk() for every file and folder.
+//
+// If listing a folder fails, the function is called with the folder's
+// path and the error. Returning fs.SkipDir for a folder skips its content,
+// returning it for a file skips the remaining entries of the parent folder.
+// Any other error stops the walk and is returned by Walk().
+type WalkFunc func(path string, entry *FileListEntry, err error) error
+
+// WalkOptions controls the traversal of Walk().
+type WalkOptions struct {
+	// Concurrency is the number of folders being listed concurrently.
+	// A value of 1 or less walks sequentially.
+	Concurrency int
+
+	// MaxDepth limits the depth of the traversal. A value of 1 only visits
+	// the entries of the root folder. Zero means no limit.
+	MaxDepth int
+
+	// Ordered visits the entries depth-first in lexical order, like
+	// fs.WalkDir(). This disables concurrency.
+	Ordered bool
+
+	// PageSize is the number of entries retrieved per request,
+	// when listing a folder. Zero uses the default of 1000 entries.
+	PageSize int
+}
+
+// folderLister retrieves all entries of a folder.
+type folderLister func(ctx context.Context, path string) ([]FileListEntry, error)
+
+// Walk traverses the folder tree below root and calls fn for every
+// file and folder. The root itself is not passed to fn.
+//
+// Unless WalkOptions.Ordered is set, fn is called from multiple goroutines
+// (never concurrently) and the order of the entries is not defined.
+func (s *FileStationSession) Walk(ctx context.Context, root string, fn WalkFunc, opts WalkOptions) error {
+	root, err := checkPath("Walk", root)
+	if err != nil {
+		return err
+	}
+
+	return walk(ctx, root, fn, opts, func(ctx context.Context, path string) ([]FileListEntry, error) {
+		return s.getFileListInternal(ctx, path, opts.PageSize)
+	})
+}
+
+func walk(ctx context.Context, root string, fn WalkFunc, opts WalkOptions, list folderLister) error {
+	if opts.Ordered || opts.Concurrency <= 1 {
+		err := walkOrdered(ctx, root, 1, fn, opts, list)
+		if err == fs.SkipDir {
+			return nil
+		}
+		return err
+	}
+
+	return walkConcurrent(ctx, root, fn, opts, list)
+}
+
+func walkOrdered(ctx context.Context, dir string, depth int, fn WalkFunc, opts WalkOptions, list folderLister) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	entries, err := list(ctx, dir)
+	if err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		return fn(dir, nil, err)
+	}
+
+	if opts.Ordered {
+		sort.Slice(entries, func(i, j int) bool { return entries[i].Name < entries[j].Name })
+	}
+
+	for i := range entries {
+		e := &entries[i]
+		p := path.Join(dir, e.Name)
+
+		if err := fn(p, e, nil); err != nil {
+			if err == fs.SkipDir {
+				if e.IsDir() {
+					continue
+				}
+				return nil
+			}
+			return err
+		}
+
+		if e.IsDir() && (opts.MaxDepth <= 0 || depth < opts.MaxDepth) {
+			if err := walkOrdered(ctx, p, depth+1, fn, opts, list); err != nil && err != fs.SkipDir {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+type walkJob struct {
+	path  string
+	depth int
+}
+
+// walkQueue is the queue of folders to be listed by the workers.
+type walkQueue struct {
+	mu      sync.Mutex
+	cond    *sync.Cond
+	jobs    []walkJob
+	pending int // queued or in progress
+	err     error
+}
+
+func walkConcurrent(ctx context.Context, root string, fn WalkFunc, opts WalkOptions, list folderLister) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	q := &walkQueue{
+		jobs:    []walkJob{{path: root, depth: 1}},
+		pending: 1,
+	}
+	q.cond = sync.NewCond(&q.mu)
+
+	// stop waiting workers if cancelled
+	go func() {
+		<-ctx.Done()
+		q.mu.Lock()
+		q.cond.Broadcast()
+		q.mu.Unlock()
+	}()
+
+	var fnLock sync.Mutex
+	var wg sync.WaitGroup
+
+	for i := 0; i < opts.Concurrency; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			for {
+				job, ok := q.next(ctx)
+				if !ok {
+					return
+				}
+
+				err := walkFolder(ctx, job, fn, &fnLock, opts, list, q)
+				if err != nil {
+					q.fail(err)
+					cancel()
+				}
+
+				q.done()
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	if q.err != nil {
+		return q.err
+	}
+	return ctx.Err()
+}
+
+// walkFolder lists a single folder and queues its sub-folders.
+func walkFolder(ctx context.Context, job walkJob, fn WalkFunc, fnLock *sync.Mutex, opts WalkOptions, list folderLister, q *walkQueue) error {
+	entries, err := list(ctx, job.path)
+
+	fnLock.Lock()
+	defer fnLock.Unlock()
+
+	if ctx.Err() != nil {
+		return nil
+	}
+
+	if err != nil {
+		if err := fn(job.path, nil, err); err != nil && err != fs.SkipDir {
+			return err
+		}
+		return nil
+	}
+
+	for i := range entries {
+		e := &entries[i]
+		p := path.Join(job.path, e.Name)
+
+		if err := fn(p, e, nil); err != nil {
+			if err == fs.SkipDir {
+				if e.IsDir() {
+					continue
+				}
+				return nil
+			}
+			return err
+		}
+
+		if e.IsDir() && (opts.MaxDepth <= 0 || job.depth < opts.MaxDepth) {
+			q.push(walkJob{path: p, depth: job.depth + 1})
+		}
+	}
+
+	return nil
+}
+
+func (q *walkQueue) next(ctx context.Context) (walkJob, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for len(q.jobs) == 0 && q.pending > 0 && ctx.Err() == nil {
+		q.cond.Wait()
+	}
+
+	if len(q.jobs) == 0 || ctx.Err() != nil {
+		return walkJob{}, false
+	}
+
+	job := q.jobs[len(q.jobs)-1]
+	q.jobs = q.jobs[:len(q.jobs)-1]
+	return job, true
+}
+
+func (q *walkQueue) push(job walkJob) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.jobs = append(q.jobs, job)
+	q.pending++
+	q.cond.Signal()
+}
+
+func (q *walkQueue) done() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.pending--
+	if q.pending == 0 {
+		q.cond.Broadcast()
+	}
+}
+
+func (q *walkQueue) fail(err error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.err == nil {
+		q.err = err
+	}
+}
@@ -0,0 +1,201 @@
+package filestation
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"net/http"
+	"path"
+	"sort"
+	"strconv"
+	"sync"
+	"testing"
+)
+
+// testTree creates a lister for a folder tree with the given fan-out and depth.
+func testTree(fanout, depth int) folderLister {
+	return func(ctx context.Context, dir string) ([]FileListEntry, error) {
+		level := 0
+		if dir != "/share" {
+			level = len(splitTestPath(dir)) - 1
+		}
+
+		var ret []FileListEntry
+		for i := fanout - 1; i >= 0; i-- {
+			ret = append(ret, FileListEntry{Name: "file" + strconv.Itoa(i)})
+			if level < depth {
+				ret = append(ret, FileListEntry{Name: "dir" + strconv.Itoa(i), IsFolder: 1})
+			}
+		}
+		return ret, nil
+	}
+}
+
+func splitTestPath(p string) []string {
+	var ret []string
+	for p != "/" && p != "." {
+		ret = append(ret, path.Base(p))
+		p = path.Dir(p)
+	}
+	return ret
+}
+
+func collectWalk(t *testing.T, opts WalkOptions, list folderLister, fn WalkFunc) ([]string, error) {
+	var mu sync.Mutex
+	var ret []string
+
+	err := walk(context.Background(), "/share", func(p string, e *FileListEntry, err error) error {
+		mu.Lock()
+		ret = append(ret, p)
+		mu.Unlock()
+
+		if fn != nil {
+			return fn(p, e, err)
+		}
+		return err
+	}, opts, list)
+
+	return ret, err
+}
+
+func TestWalkOrdered(t *testing.T) {
+	paths, err := collectWalk(t, WalkOptions{Ordered: true}, testTree(2, 1), nil)
+	if err != nil {
+		t.Fatalf("Failed to walk: %v", err)
+	}
+
+	expected := []string{
+		"/share/dir0", "/share/dir0/file0", "/share/dir0/file1",
+		"/share/dir1", "/share/dir1/file0", "/share/dir1/file1",
+		"/share/file0", "/share/file1",
+	}
+	if len(paths) != len(expected) {
+		t.Fatalf("Wrong paths: %v", paths)
+	}
+	for i := range expected {
+		if paths[i] != expected[i] {
+			t.Fatalf("Wrong order: %v", paths)
+		}
+	}
+}
+
+func TestWalkConcurrent(t *testing.T) {
+	sequential, err := collectWalk(t, WalkOptions{}, testTree(3, 3), nil)
+	if err != nil {
+		t.Fatalf("Failed to walk: %v", err)
+	}
+
+	concurrent, err := collectWalk(t, WalkOptions{Concurrency: 4}, testTree(3, 3), nil)
+	if err != nil {
+		t.Fatalf("Failed to walk concurrently: %v", err)
+	}
+
+	sort.Strings(sequential)
+	sort.Strings(concurrent)
+
+	if len(sequential) != 3+9+27+81+(3+9+27) {
+		t.Fatalf("Wrong number of entries: %v", len(sequential))
+	}
+	if len(concurrent) != len(sequential) {
+		t.Fatalf("Concurrent walk returned %v entries, expected %v", len(concurrent), len(sequential))
+	}
+	for i := range sequential {
+		if sequential[i] != concurrent[i] {
+			t.Fatalf("Concurrent walk returned wrong entry: %v", concurrent[i])
+		}
+	}
+}
+
+func TestWalkSkipAndDepth(t *testing.T) {
+	for _, concurrency := range []int{1, 4} {
+		paths, err := collectWalk(t, WalkOptions{Concurrency: concurrency, MaxDepth: 2}, testTree(2, 5), func(p string, e *FileListEntry, err error) error {
+			if path.Base(p) == "dir1" {
+				return fs.SkipDir
+			}
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("Failed to walk: %v", err)
+		}
+
+		for _, p := range paths {
+			if len(splitTestPath(p)) > 3 {
+				t.Fatalf("Walked too deep: %v", p)
+			}
+			if path.Base(path.Dir(p)) == "dir1" {
+				t.Fatalf("Walked into skipped folder: %v", p)
+			}
+		}
+		if len(paths) != 4+4 {
+			t.Fatalf("Wrong number of entries: %v", paths)
+		}
+	}
+}
+
+func TestWalkAbort(t *testing.T) {
+	abort := errors.New("abort")
+
+	for _, concurrency := range []int{1, 4} {
+		_, err := collectWalk(t, WalkOptions{Concurrency: concurrency}, testTree(3, 10), func(p string, e *FileListEntry, err error) error {
+			if path.Base(p) == "file2" {
+				return abort
+			}
+			return nil
+		})
+		if err != abort {
+			t.Fatalf("Expected walk to be aborted, got %v", err)
+		}
+	}
+}
+
+func TestWalkListError(t *testing.T) {
+	failure := errors.New("failure")
+
+	list := func(ctx context.Context, dir string) ([]FileListEntry, error) {
+		if dir == "/share/dir0" {
+			return nil, failure
+		}
+		return testTree(1, 1)(ctx, dir)
+	}
+
+	var reported error
+	_, err := collectWalk(t, WalkOptions{}, list, func(p string, e *FileListEntry, err error) error {
+		if err != nil {
+			reported = err
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Failed to walk: %v", err)
+	}
+	if reported != failure {
+		t.Fatalf("Expected list error to be reported, got %v", reported)
+	}
+}
+
+func TestWalkPageSize(t *testing.T) {
+	for _, tt := range []struct {
+		pageSize int
+		limit    string
+	}{
+		{0, strconv.Itoa(defaultPageSize)},
+		{50, "50"},
+	} {
+		var limit string
+
+		s, _ := createStubSession(t, nil, func(w http.ResponseWriter, r *http.Request) {
+			limit = r.URL.Query().Get("limit")
+			w.Write([]byte(`{"total":0,"datas":[]}`))
+		})
+
+		err := s.Walk(context.Background(), "/share", func(path string, entry *FileListEntry, err error) error {
+			return err
+		}, WalkOptions{PageSize: tt.pageSize})
+		if err != nil {
+			t.Fatalf("Failed to walk: %v", err)
+		}
+		if limit != tt.limit {
+			t.Errorf("page size %v: wrong limit %q", tt.pageSize, limit)
+		}
+	}
+}
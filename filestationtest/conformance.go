@@ -0,0 +1,354 @@
+package filestationtest
+
+import (
+	"errors"
+	"github.com/nine-lives-later/go-qnap-filestation"
+	"io/fs"
+	"math/rand"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// TestFileStation runs the conformance tests against an implementation
+// of filestation.FileStation, e.g. a session with a QNAP system, a session
+// with the fake Server or a filestation.MemoryFileStation. It ensures the
+// fakes behave like the QNAP system.
+//
+// The tests use a temporary folder, see TempFolder().
+func TestFileStation(t *testing.T, s filestation.FileStation) {
+	rnd := rand.New(rand.NewSource(time.Now().UnixNano()))
+
+	// create test folder within a temporary folder
+	testFolderPath := TempFolder(t, s) + "/roundtrip"
+
+	t.Logf("Using unit test folder: %v", testFolderPath)
+
+	t.Run("TestFolderExists1", func(t *testing.T) {
+		exists, err := s.GetFileStat(testFolderPath)
+		if err != nil {
+			t.Fatalf("Failed retrieve file stat: %v", err)
+		}
+
+		if exists != nil {
+			t.Fatal("Expected folder to not exist")
+		}
+	})
+
+	t.Run("CreateTestFolder", func(t *testing.T) {
+		created, err := s.CreateFolder(testFolderPath)
+		if err != nil {
+			t.Fatalf("Failed create test folder: %v", err)
+		}
+
+		if !created {
+			t.Fatalf("Expected the test folder to not exist")
+		}
+	})
+
+	t.Run("TryCreateTestFolder", func(t *testing.T) {
+		created, err := s.CreateFolder(testFolderPath)
+		if err != nil {
+			t.Fatalf("Failed create test folder: %v", err)
+		}
+
+		if created {
+			t.Fatalf("Expected the test folder to already exist")
+		}
+	})
+
+	// test file/folder exists
+	t.Run("TestFolderExists2", func(t *testing.T) {
+		exists, err := s.GetFileStat(testFolderPath)
+		if err != nil {
+			t.Fatalf("Failed retrieve file stat: %v", err)
+		}
+
+		if exists == nil {
+			t.Fatal("Expected folder to exist")
+		}
+	})
+
+	t.Run("CreateTestFolder-Level2", func(t *testing.T) {
+		created, err := s.CreateFolder(testFolderPath + "/test")
+		if err != nil {
+			t.Fatalf("Failed create test folder: %v", err)
+		}
+
+		if !created {
+			t.Fatalf("Expected the test folder to not exist")
+		}
+	})
+
+	t.Run("TryCreateTestFolder-Level4", func(t *testing.T) {
+		_, err := s.CreateFolder(testFolderPath + "/test/do0esNotEx1st/test4")
+		if err == nil {
+			t.Fatal("Creating test folder should fail")
+		}
+	})
+
+	t.Run("EnsureTestFolder-Level4", func(t *testing.T) {
+		created, err := s.EnsureFolder(testFolderPath + "/test/ensure/test4")
+		if err != nil {
+			t.Fatalf("Failed create test folder: %v", err)
+		}
+
+		if created <= 0 {
+			t.Fatalf("Expected the folder to not exist")
+		}
+	})
+
+	t.Run("TryEnsureTestFolder-Level4", func(t *testing.T) {
+		created, err := s.EnsureFolder(testFolderPath + "/test/ensure/test4")
+		if err != nil {
+			t.Fatalf("Failed create test folder: %v", err)
+		}
+
+		if created != 0 {
+			t.Fatalf("Expected the folder to already exist")
+		}
+	})
+
+	// test modifying privilege
+	t.Run("SetPrivilegeRecursive-Level3", func(t *testing.T) {
+		err := s.SetPrivilege(testFolderPath+"/test/ensure", 0751, true)
+		if err != nil {
+			t.Fatalf("Failed set privilege on parent folder: %v", err)
+		}
+	})
+
+	t.Run("GetAndCheckPrivilege-Level4", func(t *testing.T) {
+		stat, err := s.GetFileStat(testFolderPath + "/test/ensure/test4")
+		if err != nil {
+			t.Fatalf("Failed get stats on folder: %v", err)
+		}
+		p := filestation.NewPrivilegeFromOctal(stat.Privilege)
+		if p != 0751 {
+			t.Fatalf("Expected changed privilege on sub-folder")
+		}
+	})
+
+	// list folders
+	t.Run("GetFileList-Level2", func(t *testing.T) {
+		folders, err := s.GetFileList(testFolderPath)
+		if err != nil {
+			t.Fatalf("Failed retrieve folder list: %v", err)
+		}
+
+		if len(folders) != 1 {
+			t.Fatal("Expected one single file entry to exist")
+		}
+		if folders[0].IsFolder == 0 {
+			t.Fatal("Expected file entry to be a folder")
+		}
+		if folders[0].Name != "test" {
+			t.Fatal("Expected folder name to be 'test'")
+		}
+	})
+
+	t.Run("GetFileList-Missing", func(t *testing.T) {
+		_, err := s.GetFileList(testFolderPath + "/D0esN0tEx1st")
+		if !errors.Is(err, fs.ErrNotExist) {
+			t.Fatalf("Expected listing a missing folder to fail: %v", err)
+		}
+	})
+
+	// fill test folder
+	const fillFolders = 9
+
+	t.Run("FillTestFolder-Level2", func(t *testing.T) {
+		for i := 0; i < fillFolders; i++ {
+			_, err := s.CreateFolder(testFolderPath + "/test-" + strconv.Itoa(int(rnd.Int31())))
+			if err != nil {
+				t.Fatalf("Failed create test folder: %v", err)
+			}
+		}
+	})
+
+	// test paging
+	t.Run("FileListPaging", func(t *testing.T) {
+		folders, err := s.GetFileList(testFolderPath)
+		if err != nil {
+			t.Fatalf("Failed retrieve folder list: %v", err)
+		}
+
+		folders2, err := s.GetFileListWithOptions(testFolderPath, filestation.ListOptions{PageSize: 1})
+		if err != nil {
+			t.Fatalf("Failed retrieve folder list: %v", err)
+		}
+
+		if len(folders) != len(folders2) {
+			t.Fatal("Getting file list via paging did not return the same amount of files")
+		}
+	})
+
+	// walk via io/fs
+	t.Run("WalkDir-Level2", func(t *testing.T) {
+		count := 0
+
+		err := fs.WalkDir(filestation.NewFS(s, testFolderPath), ".", func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if !d.IsDir() {
+				t.Fatalf("Expected only folders: %v", path)
+			}
+			count++
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("Failed to walk test folder: %v", err)
+		}
+
+		// the root, test, test/ensure, test/ensure/test4 and the fill folders
+		expected := 4 + fillFolders
+		if count < expected {
+			t.Fatalf("Expected at least %v folders, got %v", expected, count)
+		}
+	})
+
+	// write via io/fs
+	t.Run("WritableFS-Level2", func(t *testing.T) {
+		w := filestation.NewWritableFS(s, testFolderPath)
+
+		f, err := w.Create("test.txt")
+		if err != nil {
+			t.Fatalf("Failed to create file: %v", err)
+		}
+		if _, err := f.Write([]byte("hello world")); err != nil {
+			t.Fatalf("Failed to write file: %v", err)
+		}
+		if err := f.Close(); err != nil {
+			t.Fatalf("Failed to upload file: %v", err)
+		}
+
+		if err := w.Rename("test.txt", "test/renamed.txt"); err != nil {
+			t.Fatalf("Failed to rename file: %v", err)
+		}
+
+		content, err := w.ReadFile("test/renamed.txt")
+		if err != nil {
+			t.Fatalf("Failed to read file: %v", err)
+		}
+		if string(content) != "hello world" {
+			t.Fatalf("Wrong file content: %q", content)
+		}
+
+		if err := w.Remove("test/renamed.txt"); err != nil {
+			t.Fatalf("Failed to remove file: %v", err)
+		}
+		if err := w.Remove("test/renamed.txt"); !errors.Is(err, fs.ErrNotExist) {
+			t.Fatalf("Expected file to not exist: %v", err)
+		}
+	})
+
+	// test file/folder does not exists
+	t.Run("FileDoesNotExist", func(t *testing.T) {
+		exists, err := s.GetFileStat(testFolderPath + "/D0esN0tEx1st!!__")
+		if err != nil {
+			t.Fatalf("Failed retrieve file stat: %v", err)
+		}
+
+		if exists != nil {
+			t.Fatal("Expected file to be missing")
+		}
+	})
+
+	t.Run("TryDeleteFolder-Level2", func(t *testing.T) {
+		deleted, err := s.DeleteFileNoRecycleBin(testFolderPath + "/D0esN0tEx1st456")
+		if err != nil {
+			t.Fatalf("Failed to delete folder: %v", err)
+		}
+
+		if deleted {
+			t.Fatal("Expected folder to not be deleted")
+		}
+	})
+
+	t.Run("TryDeleteFolderWithResult-Level2", func(t *testing.T) {
+		result, err := s.Delete(testFolderPath+"/D0esN0tEx1st456", false)
+		if err != nil {
+			t.Fatalf("Failed to delete folder: %v", err)
+		}
+
+		if result != filestation.DeleteResult_NotFound {
+			t.Fatalf("Expected folder to be not found, got %v", result)
+		}
+	})
+
+	t.Run("DeleteFolderWithResult-Level3", func(t *testing.T) {
+		result, err := s.Delete(testFolderPath+"/test/ensure/test4", true)
+		if err != nil {
+			t.Fatalf("Failed to delete folder: %v", err)
+		}
+
+		if result != filestation.DeleteResult_Deleted {
+			t.Fatalf("Expected folder to be deleted, got %v", result)
+		}
+	})
+
+	t.Run("DeleteFolderRecycleBin-Level3", func(t *testing.T) {
+		if _, err := s.CreateFolder(testFolderPath + "/test/recycle"); err != nil {
+			t.Fatalf("Failed create test folder: %v", err)
+		}
+
+		deleted, err := s.DeleteFile(testFolderPath + "/test/recycle")
+		if err != nil {
+			t.Fatalf("Failed to delete folder: %v", err)
+		}
+		if !deleted {
+			t.Fatal("Expected folder to be deleted")
+		}
+
+		exists, err := s.GetFileStat(testFolderPath + "/test/recycle")
+		if err != nil {
+			t.Fatalf("Failed retrieve file stat: %v", err)
+		}
+		if exists != nil {
+			t.Fatal("Expected folder to not exist")
+		}
+	})
+
+	t.Run("TryDeleteFolderRecycleBin-Level3", func(t *testing.T) {
+		deleted, err := s.DeleteFile(testFolderPath + "/test/recycle")
+		if err != nil {
+			t.Fatalf("Failed to delete folder: %v", err)
+		}
+		if deleted {
+			t.Fatal("Expected folder to not be deleted")
+		}
+	})
+
+	t.Run("TryDeleteFolder-Level3", func(t *testing.T) {
+		deleted, err := s.DeleteFileNoRecycleBin(testFolderPath + "/D0esN0tEx1st/test3")
+		if err == nil {
+			t.Fatal("Expected deleting folder to fail")
+		}
+
+		if deleted {
+			t.Fatal("Expected folder to not be deleted")
+		}
+	})
+
+	t.Run("DeleteTestFolder", func(t *testing.T) {
+		deleted, err := s.DeleteFileNoRecycleBin(testFolderPath)
+		if err != nil {
+			t.Fatalf("Failed to delete test folder: %v", err)
+		}
+
+		if !deleted {
+			t.Fatal("Expected test folder to be deleted")
+		}
+	})
+
+	t.Run("TestFolderExists3", func(t *testing.T) {
+		exists, err := s.GetFileStat(testFolderPath)
+		if err != nil {
+			t.Fatalf("Failed retrieve file stat: %v", err)
+		}
+
+		if exists != nil {
+			t.Fatal("Expected folder to not exist")
+		}
+	})
+}
@@ -0,0 +1,203 @@
+package filestationtest
+
+import (
+	"fmt"
+	"github.com/nine-lives-later/go-qnap-filestation"
+	"net/http"
+	"path"
+	"time"
+)
+
+// FaultType is the misbehaviour injected by a Fault.
+type FaultType int
+
+const (
+	FaultType_Latency        FaultType = iota // delays the response only
+	FaultType_DropConnection                  // closes the connection in the middle of the body
+	FaultType_HTTPError                       // responds with HTTP status 500
+	FaultType_HTMLPage                        // responds with an HTML page instead of JSON
+	FaultType_Status                          // responds with the given FileStationStatus
+	FaultType_SessionExpired                  // invalidates all sessions before handling the request
+)
+
+func (t FaultType) String() string {
+	switch t {
+	case FaultType_Latency:
+		return "Latency"
+	case FaultType_DropConnection:
+		return "DropConnection"
+	case FaultType_HTTPError:
+		return "HTTPError"
+	case FaultType_HTMLPage:
+		return "HTMLPage"
+	case FaultType_Status:
+		return "Status"
+	case FaultType_SessionExpired:
+		return "SessionExpired"
+	}
+
+	return fmt.Sprintf("FaultType(%v)", int(t))
+}
+
+// defaultHTMLPage is served by FaultType_HTMLPage, unless specified.
+const defaultHTMLPage = `<!DOCTYPE html>
+<html><head><title>503 Service Unavailable</title></head>
+<body><h1>Service Unavailable</h1><p>The server is temporarily unable to service your request.</p></body></html>
+`
+
+// Fault describes a misbehaviour of the Server, see Server.InjectFault().
+//
+//	// fail the second get_list call with a busy database
+//	srv.InjectFault(filestationtest.Fault{
+//		Func:   "get_list",
+//		Call:   2,
+//		Times:  1,
+//		Type:   filestationtest.FaultType_Status,
+//		Status: filestation.WFM2_DB_FAIL,
+//	})
+type Fault struct {
+	// Endpoint restricts the fault to a CGI, e.g. "utilRequest.cgi".
+	// Empty matches all endpoints.
+	Endpoint string
+
+	// Func restricts the fault to a function, e.g. "get_list".
+	// Empty matches all functions.
+	Func string
+
+	// Call is the first affected call, counting the matching calls
+	// from 1 since the fault has been injected. Zero affects the first call.
+	Call int
+
+	// Times is the number of affected calls. Zero affects all further calls.
+	Times int
+
+	// Type is the misbehaviour. Latency is applied to all types.
+	Type    FaultType
+	Latency time.Duration
+
+	// Status is the response of FaultType_Status.
+	Status filestation.FileStationStatus
+
+	// Body is the page served by FaultType_HTMLPage.
+	// Empty serves a generic error page.
+	Body string
+
+	calls int
+}
+
+// InjectFault adds a fault to the server. The faults are checked in the
+// order they have been added, and the first affecting one is applied.
+func (s *Server) InjectFault(f Fault) {
+	s.faultMu.Lock()
+	defer s.faultMu.Unlock()
+
+	s.faults = append(s.faults, &f)
+}
+
+// ClearFaults removes all faults.
+func (s *Server) ClearFaults() {
+	s.faultMu.Lock()
+	defer s.faultMu.Unlock()
+
+	s.faults = nil
+}
+
+// matches checks if the request is subject to the fault.
+func (f *Fault) matches(endpoint, fn string) bool {
+	return (f.Endpoint == "" || f.Endpoint == endpoint) && (f.Func == "" || f.Func == fn)
+}
+
+// active checks if the current call is affected.
+func (f *Fault) active() bool {
+	first := f.Call
+	if first <= 0 {
+		first = 1
+	}
+
+	return f.calls >= first && (f.Times <= 0 || f.calls < first+f.Times)
+}
+
+// nextFault counts the call for all matching faults and
+// returns the fault to be applied, if any.
+func (s *Server) nextFault(r *http.Request) *Fault {
+	endpoint, fn := path.Base(r.URL.Path), r.FormValue("func")
+
+	s.faultMu.Lock()
+	defer s.faultMu.Unlock()
+
+	var ret *Fault
+	for _, f := range s.faults {
+		if !f.matches(endpoint, fn) {
+			continue
+		}
+
+		f.calls++
+		if ret == nil && f.active() {
+			c := *f
+			ret = &c
+		}
+	}
+
+	return ret
+}
+
+// injectFault applies the fault for the request, if any.
+// It returns true if the request has been answered.
+func (s *Server) injectFault(w http.ResponseWriter, r *http.Request) bool {
+	f := s.nextFault(r)
+	if f == nil {
+		return false
+	}
+
+	if f.Latency > 0 {
+		select {
+		case <-time.After(f.Latency):
+		case <-r.Context().Done():
+			return true
+		}
+	}
+
+	switch f.Type {
+	case FaultType_DropConnection:
+		dropConnection(w)
+		return true
+	case FaultType_HTTPError:
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return true
+	case FaultType_HTMLPage:
+		body := f.Body
+		if body == "" {
+			body = defaultHTMLPage
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write([]byte(body))
+		return true
+	case FaultType_Status:
+		writeStatus(w, f.Status)
+		return true
+	case FaultType_SessionExpired:
+		s.mu.Lock()
+		s.sessions = make(map[string]string)
+		s.mu.Unlock()
+	}
+
+	return false
+}
+
+// dropConnection sends the headers and a part of the body,
+// before closing the connection.
+func dropConnection(w http.ResponseWriter) {
+	hj, ok := w.(http.Hijacker)
+	if !ok {
+		panic(http.ErrAbortHandler)
+	}
+
+	conn, buf, err := hj.Hijack()
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	buf.WriteString("HTTP/1.1 200 OK\r\nContent-Type: application/json\r\nContent-Length: 1024\r\n\r\n{\"status\":")
+	buf.Flush()
+}
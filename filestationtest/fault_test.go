@@ -0,0 +1,313 @@
+package filestationtest_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/nine-lives-later/go-qnap-filestation"
+	"github.com/nine-lives-later/go-qnap-filestation/filestationtest"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFaultStatus(t *testing.T) {
+	srv, s := createTestServer(t)
+
+	srv.InjectFault(filestationtest.Fault{
+		Func:   "get_tree",
+		Call:   2,
+		Times:  1,
+		Type:   filestationtest.FaultType_Status,
+		Status: filestation.WFM2_DB_FAIL,
+	})
+
+	if _, err := s.GetShareList(); err != nil {
+		t.Fatalf("Expected first call to succeed: %v", err)
+	}
+	if _, err := s.GetShareList(); !errors.Is(err, filestation.WFM2_DB_FAIL) {
+		t.Fatalf("Expected second call to fail: %v", err)
+	}
+	if _, err := s.GetShareList(); err != nil {
+		t.Fatalf("Expected third call to succeed: %v", err)
+	}
+
+	// other functions are not affected
+	srv.ClearFaults()
+	srv.InjectFault(filestationtest.Fault{
+		Func:   "get_list",
+		Type:   filestationtest.FaultType_Status,
+		Status: filestation.WFM2_PERMISSION_DENY,
+	})
+
+	if _, err := s.GetShareList(); err != nil {
+		t.Fatalf("Expected get_tree not to be affected: %v", err)
+	}
+}
+
+func TestFaultKeepAlive(t *testing.T) {
+	srv := filestationtest.NewServer(t.TempDir())
+	t.Cleanup(srv.Close)
+
+	srv.AddUser("admin", "secret")
+
+	errs := make(chan error, 1)
+
+	s, err := filestation.Connect(srv.URL, "admin", "secret", &filestation.ConfigOptions{
+		APICallTimeout:    5 * time.Second,
+		KeepAliveInterval: time.Millisecond,
+		KeepAliveErrorHandler: func(err error) {
+			select {
+			case errs <- err:
+			default:
+			}
+		},
+	})
+	if err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+	t.Cleanup(func() { s.Close() })
+
+	srv.InjectFault(filestationtest.Fault{
+		Func:   "get_tree",
+		Type:   filestationtest.FaultType_Status,
+		Status: filestation.WFM2_PERMISSION_DENY,
+	})
+
+	select {
+	case err := <-errs:
+		if !errors.Is(err, filestation.WFM2_PERMISSION_DENY) {
+			t.Fatalf("Expected injected fault: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected keepalive error to be reported")
+	}
+}
+
+func TestFaultRetry(t *testing.T) {
+	srv := filestationtest.NewServer(t.TempDir())
+	t.Cleanup(srv.Close)
+
+	srv.AddUser("admin", "secret")
+	srv.AddShare("share", false)
+
+	s, err := filestation.Connect(srv.URL, "admin", "secret", &filestation.ConfigOptions{
+		APICallTimeout: 5 * time.Second,
+		RetryPolicy: &filestation.RetryPolicy{
+			MaxAttempts:    3,
+			InitialBackoff: time.Millisecond,
+		},
+	})
+	if err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+	t.Cleanup(func() { s.Close() })
+
+	srv.InjectFault(filestationtest.Fault{
+		Endpoint: "utilRequest.cgi",
+		Times:    2,
+		Type:     filestationtest.FaultType_Status,
+		Status:   filestation.WFM2_DB_FAIL,
+	})
+
+	if _, err := s.GetShareList(); err != nil {
+		t.Fatalf("Expected retries to succeed: %v", err)
+	}
+}
+
+func TestFaultHTTP(t *testing.T) {
+	srv, s := createTestServer(t)
+
+	srv.InjectFault(filestationtest.Fault{Func: "get_tree", Times: 1, Type: filestationtest.FaultType_HTTPError})
+
+	var fsErr *filestation.Error
+	if _, err := s.GetShareList(); !errors.As(err, &fsErr) || fsErr.HTTPStatus != 500 {
+		t.Fatalf("Expected HTTP error: %v", err)
+	}
+
+	srv.ClearFaults()
+	srv.InjectFault(filestationtest.Fault{Func: "get_tree", Times: 1, Type: filestationtest.FaultType_HTMLPage})
+
+	if _, err := s.GetShareList(); !errors.Is(err, filestation.ErrNotJSON) {
+		t.Fatalf("Expected HTML page: %v", err)
+	}
+
+	srv.ClearFaults()
+	srv.InjectFault(filestationtest.Fault{Func: "get_tree", Times: 1, Type: filestationtest.FaultType_DropConnection})
+
+	if _, err := s.GetShareList(); err == nil {
+		t.Fatal("Expected dropped connection to fail")
+	}
+}
+
+func TestFaultLatency(t *testing.T) {
+	srv := filestationtest.NewServer(t.TempDir())
+	t.Cleanup(srv.Close)
+
+	srv.AddUser("admin", "secret")
+
+	s, err := filestation.Connect(srv.URL, "admin", "secret", &filestation.ConfigOptions{
+		APICallTimeout: 50 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+	t.Cleanup(func() { s.Close() })
+
+	srv.InjectFault(filestationtest.Fault{Func: "get_tree", Latency: 20 * time.Millisecond})
+
+	start := time.Now()
+	if _, err := s.GetShareList(); err != nil {
+		t.Fatalf("Expected delayed call to succeed: %v", err)
+	}
+	if time.Since(start) < 20*time.Millisecond {
+		t.Fatal("Expected call to be delayed")
+	}
+
+	srv.ClearFaults()
+	srv.InjectFault(filestationtest.Fault{Func: "get_tree", Latency: time.Second})
+
+	if _, err := s.GetShareList(); err == nil {
+		t.Fatal("Expected call to time out")
+	}
+}
+
+func TestFaultSessionExpired(t *testing.T) {
+	srv, s := createTestServer(t)
+
+	srv.InjectFault(filestationtest.Fault{Func: "get_tree", Times: 1, Type: filestationtest.FaultType_SessionExpired})
+
+	// the expired session is renewed
+	if _, err := s.GetShareList(); err != nil {
+		t.Fatalf("Expected session to be renewed: %v", err)
+	}
+}
+
+func TestFaultSessionExpiredConcurrent(t *testing.T) {
+	srv := filestationtest.NewServer(t.TempDir())
+	t.Cleanup(srv.Close)
+
+	srv.Version = "5.0.1"
+	srv.AddUser("admin", "secret")
+	if err := srv.AddShare("share", true); err != nil {
+		t.Fatalf("Failed to create share: %v", err)
+	}
+	for i := 0; i < 16; i++ {
+		if err := os.MkdirAll(filepath.Join(srv.Root, "share", fmt.Sprintf("folder%v", i), "sub"), 0755); err != nil {
+			t.Fatalf("Failed to create folder: %v", err)
+		}
+	}
+
+	rec := filestationtest.NewRecorder(nil)
+	s, err := filestation.Connect(srv.URL, "admin", "secret", &filestation.ConfigOptions{Transport: rec})
+	if err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+	t.Cleanup(func() { s.Close() })
+
+	srv.InjectFault(filestationtest.Fault{Func: "get_list", Call: 2, Times: 1, Type: filestationtest.FaultType_SessionExpired})
+
+	// all concurrent requests with the expired session share a single renewal
+	count := 0
+	err = s.Walk(context.Background(), "/share", func(path string, entry *filestation.FileListEntry, err error) error {
+		count++
+		return err
+	}, filestation.WalkOptions{Concurrency: 8})
+	if err != nil {
+		t.Fatalf("Expected session to be renewed: %v", err)
+	}
+	if count != 32 {
+		t.Fatalf("Expected 32 entries, got %v", count)
+	}
+
+	logins := 0
+	for _, e := range rec.Exchanges() {
+		if strings.Contains(e.Request.URL, "wfm2Login.cgi") && e.Request.Method == "POST" {
+			logins++
+		}
+		if strings.Contains(e.Request.URL, "wfm2Logout.cgi") {
+			t.Fatalf("Expected no logout during renewal: %v", e.Request.URL)
+		}
+	}
+	if logins != 2 {
+		t.Fatalf("Expected a single relogin, got %v logins", logins-1)
+	}
+}
+
+func TestFaultUploadRetry(t *testing.T) {
+	srv, _ := createTestServer(t)
+
+	s, err := filestation.Connect(srv.URL, "admin", "secret", &filestation.ConfigOptions{
+		RetryPolicy: &filestation.RetryPolicy{MaxAttempts: 2, InitialBackoff: time.Millisecond, RetryMutating: true},
+	})
+	if err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+	t.Cleanup(func() { s.Close() })
+
+	for _, ft := range []filestationtest.FaultType{filestationtest.FaultType_HTTPError, filestationtest.FaultType_SessionExpired} {
+		srv.ClearFaults()
+		srv.InjectFault(filestationtest.Fault{Func: "upload", Times: 1, Type: ft})
+
+		// a seekable body is sent again
+		if err := s.Upload("/share/seeker.txt", strings.NewReader("seekable content"), true); err != nil {
+			t.Fatalf("%v: Expected upload to be repeated: %v", ft, err)
+		}
+		if data, err := os.ReadFile(filepath.Join(srv.Root, "share", "seeker.txt")); err != nil || string(data) != "seekable content" {
+			t.Fatalf("%v: Unexpected content: %q, %v", ft, data, err)
+		}
+
+		// a consumed stream is not repeated
+		srv.ClearFaults()
+		srv.InjectFault(filestationtest.Fault{Func: "upload", Times: 1, Type: ft})
+
+		if err := s.Upload("/share/stream.txt", io.MultiReader(strings.NewReader("stream")), true); err == nil {
+			t.Fatalf("%v: Expected upload of stream not to be repeated", ft)
+		}
+	}
+}
+
+func TestFaultDownload(t *testing.T) {
+	srv, _ := createTestServer(t)
+
+	s, err := filestation.Connect(srv.URL, "admin", "secret", &filestation.ConfigOptions{
+		RetryPolicy: &filestation.RetryPolicy{MaxAttempts: 2, InitialBackoff: time.Millisecond},
+	})
+	if err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+	t.Cleanup(func() { s.Close() })
+
+	if err := os.WriteFile(filepath.Join(srv.Root, "share", "file.txt"), []byte("content"), 0666); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+
+	// temporary failures are retried and expired sessions renewed
+	for _, ft := range []filestationtest.FaultType{filestationtest.FaultType_HTTPError, filestationtest.FaultType_SessionExpired} {
+		srv.ClearFaults()
+		srv.InjectFault(filestationtest.Fault{Func: "download", Times: 1, Type: ft})
+
+		r, err := s.Download("/share/file.txt")
+		if err != nil {
+			t.Fatalf("%v: Failed to download: %v", ft, err)
+		}
+		data, err := io.ReadAll(r)
+		r.Close()
+		if err != nil || string(data) != "content" {
+			t.Fatalf("%v: Unexpected content: %q, %v", ft, data, err)
+		}
+	}
+
+	// the response is part of the error
+	srv.ClearFaults()
+	srv.InjectFault(filestationtest.Fault{Func: "download", Type: filestationtest.FaultType_Status, Status: filestation.WFM2_PERMISSION_DENY})
+
+	_, err = s.Download("/share/file.txt")
+	var e *filestation.Error
+	if !errors.As(err, &e) || e.Status != filestation.WFM2_PERMISSION_DENY || e.HTTPStatus != 200 || !strings.Contains(e.Body, "status") {
+		t.Fatalf("Expected error with response, got %+v", err)
+	}
+}
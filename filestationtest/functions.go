@@ -0,0 +1,582 @@
+package filestationtest
+
+import (
+	"errors"
+	"github.com/nine-lives-later/go-qnap-filestation"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// recycleFolderName is the folder of a share, which holds the deleted files.
+const recycleFolderName = "@Recycle"
+
+type folderEntry struct {
+	Path          string `json:"id"`
+	CLS           string `json:"cls"`
+	Text          string `json:"text"`
+	Icon          string `json:"iconCls"`
+	RecycleBin    string `json:"recycle_bin,omitempty"`
+	RecycleFolder string `json:"recycle_folder,omitempty"`
+	ItemCount     int    `json:"real_total"`
+}
+
+type fileEntry struct {
+	Name         string `json:"filename"`
+	Exists       int    `json:"exist"`
+	IsFolder     int    `json:"isfolder,omitempty"`
+	FileSize     string `json:"filesize,omitempty"`
+	Owner        string `json:"owner,omitempty"`
+	Group        string `json:"group,omitempty"`
+	Privilege    string `json:"privilege,omitempty"`
+	ModifiedDate int64  `json:"epochmt,omitempty"`
+}
+
+type fileListResponse struct {
+	Total     int         `json:"total"`
+	ItemCount int         `json:"real_total"`
+	Entries   []fileEntry `json:"datas"`
+}
+
+// utilRequest dispatches the functions of utilRequest.cgi.
+func (s *Server) utilRequest(w http.ResponseWriter, r *http.Request, user string) {
+	switch r.FormValue("func") {
+	case "get_tree":
+		s.getTree(w, r)
+	case "get_list":
+		s.getList(w, r, user)
+	case "stat":
+		s.stat(w, r, user)
+	case "createdir":
+		s.createDir(w, r)
+	case "delete":
+		s.delete(w, r)
+	case "set_privilege":
+		s.setPrivilege(w, r)
+	case "download":
+		s.download(w, r)
+	case "upload":
+		s.upload(w, r)
+	case "rename":
+		s.rename(w, r)
+	case "move":
+		s.move(w, r)
+	default:
+		writeStatus(w, filestation.WFM2_PARAMETER_ERROR)
+	}
+}
+
+func (s *Server) getTree(w http.ResponseWriter, r *http.Request) {
+	node := r.FormValue("node")
+
+	dir := "/"
+	if node != "share_root" {
+		var err error
+		if dir, err = filestation.CleanPath(node); err != nil {
+			writeStatus(w, filestation.WFM2_PARAMETER_ERROR)
+			return
+		}
+	}
+
+	entries, err := os.ReadDir(s.localPath(dir))
+	if err != nil {
+		writeStatus(w, filestation.WFM2_FILE_NO_EXIST)
+		return
+	}
+
+	ret := make([]folderEntry, 0)
+	for _, e := range entries {
+		if !e.IsDir() || filestation.IsSystemFolder(e.Name()) {
+			continue
+		}
+
+		p := path.Join(dir, e.Name())
+		children, _ := os.ReadDir(s.localPath(p))
+
+		entry := folderEntry{
+			Path:      p,
+			CLS:       "w",
+			Text:      e.Name(),
+			Icon:      "folder",
+			ItemCount: len(children),
+		}
+		if dir == "/" {
+			entry.RecycleBin = "0"
+			if s.recycleBins[e.Name()] {
+				entry.RecycleBin = "1"
+				entry.RecycleFolder = recycleFolderName
+			}
+		}
+
+		ret = append(ret, entry)
+	}
+
+	writeJSON(w, ret)
+}
+
+func (s *Server) getList(w http.ResponseWriter, r *http.Request, user string) {
+	dir, err := filestation.CleanPath(r.FormValue("path"))
+	if err != nil {
+		writeStatus(w, filestation.WFM2_FILE_NO_EXIST)
+		return
+	}
+
+	infos, err := readDir(s.localPath(dir))
+	if err != nil {
+		writeStatus(w, filestation.WFM2_FILE_NO_EXIST)
+		return
+	}
+
+	// filter and sort
+	filter := strings.ToLower(r.FormValue("filename"))
+	n := 0
+	for _, info := range infos {
+		if filter == "" || strings.Contains(strings.ToLower(info.Name()), filter) {
+			infos[n] = info
+			n++
+		}
+	}
+	infos = infos[:n]
+
+	sortInfos(infos, r.FormValue("sort"), r.FormValue("dir") == "DESC")
+
+	// apply paging
+	start, _ := strconv.Atoi(r.FormValue("start"))
+	limit, err := strconv.Atoi(r.FormValue("limit"))
+	if err != nil || limit <= 0 {
+		limit = len(infos)
+	}
+	if start < 0 || start > len(infos) {
+		start = len(infos)
+	}
+	end := start + limit
+	if end > len(infos) {
+		end = len(infos)
+	}
+
+	result := fileListResponse{
+		Total:     len(infos),
+		ItemCount: len(infos),
+		Entries:   make([]fileEntry, 0, end-start),
+	}
+	for _, info := range infos[start:end] {
+		result.Entries = append(result.Entries, newFileEntry(info, user))
+	}
+
+	writeJSON(w, result)
+}
+
+func (s *Server) stat(w http.ResponseWriter, r *http.Request, user string) {
+	name := r.FormValue("file_name")
+
+	result := fileListResponse{
+		Total:     1,
+		ItemCount: 1,
+		Entries:   []fileEntry{{Name: name}},
+	}
+
+	if p, ok := s.remotePath(r.FormValue("path"), name); ok {
+		if info, err := os.Stat(s.localPath(p)); err == nil {
+			result.Entries[0] = newFileEntry(info, user)
+		}
+	}
+
+	writeJSON(w, result)
+}
+
+func (s *Server) createDir(w http.ResponseWriter, r *http.Request) {
+	dir, name := r.FormValue("dest_path"), r.FormValue("dest_folder")
+
+	if status := validateName(name); status != filestation.WFM2_SUCCESS {
+		writeStatus(w, status)
+		return
+	}
+
+	parent, ok := s.folder(dir)
+	if !ok {
+		writeStatus(w, filestation.WFM2_FILE_NO_EXIST)
+		return
+	}
+
+	if err := os.Mkdir(filepath.Join(parent, name), 0777); err != nil {
+		writeStatus(w, statusOf(err))
+		return
+	}
+
+	writeStatus(w, filestation.WFM2_SUCCESS)
+}
+
+func (s *Server) delete(w http.ResponseWriter, r *http.Request) {
+	dir, name := r.FormValue("path"), r.FormValue("file_name")
+
+	if _, ok := s.folder(dir); !ok {
+		writeStatus(w, filestation.WFM2_FILE_NO_EXIST)
+		return
+	}
+
+	p, ok := s.remotePath(dir, name)
+	if !ok || path.Dir(p) == "/" { // shares cannot be deleted
+		writeStatus(w, filestation.WFM2_PERMISSION_DENY)
+		return
+	}
+
+	local := s.localPath(p)
+	if _, err := os.Lstat(local); err != nil {
+		writeStatus(w, filestation.WFM2_FAIL) // missing files are reported as failure
+		return
+	}
+
+	// move to the recycle bin, keeping the original folder structure
+	share, _ := filestation.ShareOf(p)
+	recycle := path.Join(share, recycleFolderName)
+
+	if r.FormValue("force") != "1" && s.recycleBins[share[1:]] && p != recycle && !strings.HasPrefix(p, recycle+"/") {
+		target := s.localPath(path.Join(recycle, strings.TrimPrefix(p, share)))
+
+		if err := os.MkdirAll(filepath.Dir(target), 0777); err != nil {
+			writeStatus(w, statusOf(err))
+			return
+		}
+		os.RemoveAll(target)
+
+		if err := os.Rename(local, target); err != nil {
+			writeStatus(w, statusOf(err))
+			return
+		}
+
+		writeStatus(w, filestation.WFM2_SUCCESS)
+		return
+	}
+
+	if err := os.RemoveAll(local); err != nil {
+		writeStatus(w, statusOf(err))
+		return
+	}
+
+	writeStatus(w, filestation.WFM2_SUCCESS)
+}
+
+func (s *Server) setPrivilege(w http.ResponseWriter, r *http.Request) {
+	p, ok := s.remotePath(r.FormValue("source_path"), r.FormValue("source_file"))
+	if !ok {
+		writeStatus(w, filestation.WFM2_FILE_NO_EXIST)
+		return
+	}
+
+	var mode fs.FileMode
+	for i, param := range []string{"bOwn_r", "bOwn_w", "bOwn_x", "bGroup_r", "bGroup_w", "bGroup_x", "bOther_r", "bOther_w", "bOther_x"} {
+		if r.FormValue(param) == "1" {
+			mode |= 0400 >> uint(i)
+		}
+	}
+
+	local := s.localPath(p)
+	if _, err := os.Stat(local); err != nil {
+		writeStatus(w, statusOf(err))
+		return
+	}
+
+	if r.FormValue("recursive") == "1" {
+		// change the children first, so folders stay accessible
+		var paths []string
+		filepath.WalkDir(local, func(p string, d fs.DirEntry, err error) error {
+			if err == nil {
+				paths = append(paths, p)
+			}
+			return nil
+		})
+		for i := len(paths) - 1; i >= 0; i-- {
+			if err := os.Chmod(paths[i], mode); err != nil {
+				writeStatus(w, statusOf(err))
+				return
+			}
+		}
+	} else if err := os.Chmod(local, mode); err != nil {
+		writeStatus(w, statusOf(err))
+		return
+	}
+
+	writeStatus(w, filestation.WFM2_SUCCESS)
+}
+
+func (s *Server) download(w http.ResponseWriter, r *http.Request) {
+	name := r.FormValue("source_file")
+
+	p, ok := s.remotePath(r.FormValue("source_path"), name)
+	if !ok {
+		writeStatus(w, filestation.WFM2_FILE_NO_EXIST)
+		return
+	}
+
+	f, err := os.Open(s.localPath(p))
+	if err != nil {
+		writeStatus(w, statusOf(err))
+		return
+	}
+	defer f.Close()
+
+	if info, err := f.Stat(); err != nil || info.IsDir() {
+		writeStatus(w, filestation.WFM2_FAIL)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/octet-stream")
+	w.Header().Set("Content-Disposition", "attachment; filename=\""+name+"\"")
+	io.Copy(w, f)
+}
+
+func (s *Server) upload(w http.ResponseWriter, r *http.Request) {
+	content, header, err := r.FormFile("file")
+	if err != nil {
+		writeStatus(w, filestation.WFM2_PARAMETER_ERROR)
+		return
+	}
+	defer content.Close()
+
+	name := header.Filename
+
+	if status := validateName(name); status != filestation.WFM2_SUCCESS {
+		writeStatus(w, status)
+		return
+	}
+
+	parent, ok := s.folder(r.FormValue("dest_path"))
+	if !ok {
+		writeStatus(w, filestation.WFM2_FILE_NO_EXIST)
+		return
+	}
+
+	local := filepath.Join(parent, name)
+	if info, err := os.Stat(local); err == nil && (info.IsDir() || r.FormValue("overwrite") != "1") {
+		writeStatus(w, filestation.WFM2_FILE_EXIST)
+		return
+	}
+
+	f, err := os.Create(local)
+	if err != nil {
+		writeStatus(w, statusOf(err))
+		return
+	}
+	_, err = io.Copy(f, content)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		writeStatus(w, filestation.WFM2_OPEN_FILE_FAIL)
+		return
+	}
+
+	writeStatus(w, filestation.WFM2_SUCCESS)
+}
+
+func (s *Server) rename(w http.ResponseWriter, r *http.Request) {
+	dir, newName := r.FormValue("path"), r.FormValue("dest_name")
+
+	if status := validateName(newName); status != filestation.WFM2_SUCCESS {
+		writeStatus(w, status)
+		return
+	}
+
+	p, ok := s.remotePath(dir, r.FormValue("source_name"))
+	if !ok {
+		writeStatus(w, filestation.WFM2_FILE_NO_EXIST)
+		return
+	}
+	if path.Dir(p) == "/" { // shares cannot be renamed
+		writeStatus(w, filestation.WFM2_PERMISSION_DENY)
+		return
+	}
+
+	local := s.localPath(p)
+	target := filepath.Join(filepath.Dir(local), newName)
+
+	if _, err := os.Lstat(local); err != nil {
+		writeStatus(w, statusOf(err))
+		return
+	}
+	if _, err := os.Lstat(target); err == nil && target != local {
+		writeStatus(w, filestation.WFM2_FILE_EXIST)
+		return
+	}
+
+	if err := os.Rename(local, target); err != nil {
+		writeStatus(w, statusOf(err))
+		return
+	}
+
+	writeStatus(w, filestation.WFM2_SUCCESS)
+}
+
+func (s *Server) move(w http.ResponseWriter, r *http.Request) {
+	name := r.FormValue("source_file")
+
+	p, ok := s.remotePath(r.FormValue("source_path"), name)
+	if !ok {
+		writeStatus(w, filestation.WFM2_FILE_NO_EXIST)
+		return
+	}
+	if path.Dir(p) == "/" { // shares cannot be moved
+		writeStatus(w, filestation.WFM2_PERMISSION_DENY)
+		return
+	}
+
+	local := s.localPath(p)
+	if _, err := os.Lstat(local); err != nil {
+		writeStatus(w, statusOf(err))
+		return
+	}
+
+	dest, err := filestation.CleanPath(r.FormValue("dest_path"))
+	if err != nil {
+		writeStatus(w, filestation.WFM2_DES_FILE_NO_EXIST)
+		return
+	}
+	destLocal, ok := s.folder(dest)
+	if !ok {
+		writeStatus(w, filestation.WFM2_DES_FILE_NO_EXIST)
+		return
+	}
+	if dest == p || strings.HasPrefix(dest, p+"/") { // into itself
+		writeStatus(w, filestation.WFM2_PARAMETER_ERROR)
+		return
+	}
+
+	target := filepath.Join(destLocal, name)
+	if _, err := os.Lstat(target); err == nil {
+		writeStatus(w, filestation.WFM2_FILE_EXIST)
+		return
+	}
+
+	if err := os.Rename(local, target); err != nil {
+		writeStatus(w, statusOf(err))
+		return
+	}
+
+	writeStatus(w, filestation.WFM2_SUCCESS)
+}
+
+// remotePath joins the folder and name of a request into a clean path.
+func (s *Server) remotePath(dir, name string) (string, bool) {
+	if name == "" || strings.Contains(name, "/") {
+		return "", false
+	}
+
+	p, err := filestation.CleanPath(path.Join(dir, name))
+	if err != nil {
+		return "", false
+	}
+
+	return p, true
+}
+
+// localPath maps a clean remote path to the local file system.
+func (s *Server) localPath(p string) string {
+	return filepath.Join(s.Root, filepath.FromSlash(p))
+}
+
+// folder returns the local path of an existing folder.
+func (s *Server) folder(dir string) (string, bool) {
+	dir, err := filestation.CleanPath(dir)
+	if err != nil {
+		return "", false
+	}
+
+	local := s.localPath(dir)
+	if info, err := os.Stat(local); err != nil || !info.IsDir() {
+		return "", false
+	}
+
+	return local, true
+}
+
+func readDir(dir string) ([]fs.FileInfo, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	infos := make([]fs.FileInfo, 0, len(entries))
+	for _, e := range entries {
+		if info, err := e.Info(); err == nil {
+			infos = append(infos, info)
+		}
+	}
+
+	return infos, nil
+}
+
+// sortInfos sorts the entries by the sort parameter of get_list.
+func sortInfos(infos []fs.FileInfo, field string, descending bool) {
+	sort.SliceStable(infos, func(i, j int) bool {
+		a, b := infos[i], infos[j]
+		if descending {
+			a, b = b, a
+		}
+
+		switch field {
+		case "filesize":
+			if a.Size() != b.Size() {
+				return a.Size() < b.Size()
+			}
+		case "mt":
+			if !a.ModTime().Equal(b.ModTime()) {
+				return a.ModTime().Before(b.ModTime())
+			}
+		case "filetype":
+			if ea, eb := strings.ToLower(path.Ext(a.Name())), strings.ToLower(path.Ext(b.Name())); ea != eb {
+				return ea < eb
+			}
+		}
+
+		return a.Name() < b.Name()
+	})
+}
+
+func newFileEntry(info fs.FileInfo, user string) fileEntry {
+	e := fileEntry{
+		Name:         info.Name(),
+		Exists:       1,
+		FileSize:     strconv.FormatInt(info.Size(), 10),
+		Owner:        user,
+		Group:        "administrators",
+		Privilege:    strconv.FormatUint(uint64(info.Mode().Perm()), 8),
+		ModifiedDate: info.ModTime().Unix(),
+	}
+
+	if info.IsDir() {
+		e.IsFolder = 1
+		e.FileSize = "4096"
+	}
+
+	return e
+}
+
+// validateName checks the name the same way as the QNAP system.
+func validateName(name string) filestation.FileStationStatus {
+	var nameErr *filestation.NameError
+	if err := filestation.ValidateName(name); errors.As(err, &nameErr) {
+		return nameErr.Status
+	}
+
+	return filestation.WFM2_SUCCESS
+}
+
+// statusOf converts a local file system error into a status.
+func statusOf(err error) filestation.FileStationStatus {
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		return filestation.WFM2_FILE_NO_EXIST
+	case errors.Is(err, fs.ErrExist):
+		return filestation.WFM2_FILE_EXIST
+	case errors.Is(err, fs.ErrPermission):
+		return filestation.WFM2_PERMISSION_DENY
+	}
+
+	return filestation.WFM2_FAIL
+}
@@ -0,0 +1,86 @@
+package filestationtest
+
+import (
+	"fmt"
+	"github.com/nine-lives-later/go-qnap-filestation"
+	"os"
+	"sort"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// tempFolderCounter makes the names of temporary folders unique within the process.
+var tempFolderCounter int64
+
+// Connect creates a session with the QNAP system configured by the
+// environment variables QNAP_HOSTNAME, QNAP_USER and QNAP_PWD. The test
+// fails if they are not set. The session is closed when the test completes.
+func Connect(t testing.TB, options *filestation.ConfigOptions) *filestation.FileStationSession {
+	t.Helper()
+
+	host, username, password := os.Getenv("QNAP_HOSTNAME"), os.Getenv("QNAP_USER"), os.Getenv("QNAP_PWD")
+	if host == "" || username == "" || password == "" {
+		t.Fatal("filestationtest: no QNAP system configured, please set QNAP_HOSTNAME, QNAP_USER and QNAP_PWD")
+	}
+
+	s, err := filestation.Connect(host, username, password, options)
+	if err != nil {
+		t.Fatalf("filestationtest: failed to connect to %v: %v", host, err)
+	}
+
+	t.Cleanup(func() { s.Close() })
+
+	return s
+}
+
+// TempFolder creates a uniquely named folder for the test and returns its
+// path. The folder is created on the first writable share, preferring other
+// shares than "/home" and "/Public". When the test and all its subtests
+// complete, the folder is deleted permanently, bypassing the recycle bin.
+func TempFolder(t testing.TB, s filestation.FileStation) string {
+	t.Helper()
+
+	shares, err := s.GetShareList()
+	if err != nil {
+		t.Fatalf("filestationtest: failed to retrieve share list: %v", err)
+	}
+
+	sort.SliceStable(shares, func(i, j int) bool {
+		return !isCommonShare(shares[i].Path) && isCommonShare(shares[j].Path)
+	})
+
+	name := filestation.SanitizeName(fmt.Sprintf("unit-test-%v-%v-%v", t.Name(),
+		strconv.FormatInt(time.Now().UnixNano(), 36), atomic.AddInt64(&tempFolderCounter, 1)))
+
+	var errs []error
+	for _, share := range shares {
+		p := share.Path + "/" + name
+
+		created, err := s.CreateFolder(p)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if !created {
+			continue
+		}
+
+		t.Cleanup(func() {
+			if _, err := s.DeleteFileNoRecycleBin(p); err != nil {
+				t.Errorf("filestationtest: failed to delete temporary folder %v: %v", p, err)
+			}
+		})
+
+		return p
+	}
+
+	t.Fatalf("filestationtest: no writable share found among %v shares: %v", len(shares), errs)
+	return ""
+}
+
+// isCommonShare checks if the share is used by all users of the QNAP system.
+func isCommonShare(path string) bool {
+	return path == "/home" || path == "/Public"
+}
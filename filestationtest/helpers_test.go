@@ -0,0 +1,95 @@
+package filestationtest_test
+
+import (
+	"github.com/nine-lives-later/go-qnap-filestation"
+	"github.com/nine-lives-later/go-qnap-filestation/filestationtest"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestTempFolder(t *testing.T) {
+	_, s := createTestServer(t)
+
+	var folder string
+	t.Run("Create", func(t *testing.T) {
+		folder = filestationtest.TempFolder(t, s)
+
+		if !strings.HasPrefix(folder, "/nobin/unit-test-TestTempFolder_Create-") {
+			t.Fatalf("Wrong folder: %v", folder)
+		}
+		if other := filestationtest.TempFolder(t, s); other == folder {
+			t.Fatal("Expected unique folders")
+		}
+	})
+
+	if stat, err := s.GetFileStat(folder); err != nil || stat != nil {
+		t.Fatalf("Expected folder to be deleted: %+v %v", stat, err)
+	}
+}
+
+func TestTempFolderPermanent(t *testing.T) {
+	m := filestation.NewMemoryFileStation()
+	m.AddShare("Public", true)
+	m.AddShare("share", true)
+
+	var folder string
+	t.Run("Create", func(t *testing.T) {
+		folder = filestationtest.TempFolder(t, m)
+
+		if !strings.HasPrefix(folder, "/share/") {
+			t.Fatalf("Expected share to be preferred: %v", folder)
+		}
+	})
+
+	list, err := m.GetFileList("/share")
+	if err != nil || len(list) != 0 {
+		t.Fatalf("Expected folder to be deleted permanently: %+v %v", list, err)
+	}
+}
+
+func TestTempFolderReadOnly(t *testing.T) {
+	m := filestation.NewMemoryFileStation()
+	m.AddShare("share", true)
+	m.AddShare("Public", true)
+	m.SetPrivilege("/share", 0755, false)
+	m.User = "guest"
+
+	folder := filestationtest.TempFolder(t, m)
+	if !strings.HasPrefix(folder, "/Public/") {
+		t.Fatalf("Expected writable share to be used: %v", folder)
+	}
+}
+
+// setenv sets the environment variable until the test completes.
+// It replaces t.Setenv(), which requires Go 1.17.
+func setenv(t *testing.T, key, value string) {
+	old, ok := os.LookupEnv(key)
+	if err := os.Setenv(key, value); err != nil {
+		t.Fatalf("Failed to set %v: %v", key, err)
+	}
+
+	t.Cleanup(func() {
+		if ok {
+			os.Setenv(key, old)
+		} else {
+			os.Unsetenv(key)
+		}
+	})
+}
+
+func TestConnect(t *testing.T) {
+	srv := filestationtest.NewServer(t.TempDir())
+	t.Cleanup(srv.Close)
+
+	srv.AddUser("admin", "secret")
+
+	setenv(t, "QNAP_HOSTNAME", srv.URL)
+	setenv(t, "QNAP_USER", "admin")
+	setenv(t, "QNAP_PWD", "secret")
+
+	s := filestationtest.Connect(t, nil)
+	if _, err := s.GetShareList(); err != nil {
+		t.Fatalf("Failed to use session: %v", err)
+	}
+}
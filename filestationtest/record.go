@@ -0,0 +1,365 @@
+package filestationtest
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"github.com/nine-lives-later/go-qnap-filestation"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// redactedParams lists the parameters and response fields, which are
+// replaced in recordings, as they contain credentials or session IDs.
+var redactedParams = filestation.RedactedParams()
+
+// redactedValue replaces the values of the redacted parameters.
+const redactedValue = "REDACTED"
+
+// recordedHeaders lists the response headers kept in recordings.
+var recordedHeaders = []string{"Content-Type", "Content-Disposition"}
+
+// Exchange is a recorded pair of request and response.
+type Exchange struct {
+	Request  RecordedRequest  `json:"request"`
+	Response RecordedResponse `json:"response"`
+}
+
+// RecordedRequest is a request with the credentials and session IDs redacted.
+// The URL only contains the path and query, so recordings are independent
+// of the host. Form is only set for URL-encoded request bodies.
+type RecordedRequest struct {
+	Method string `json:"method"`
+	URL    string `json:"url"`
+	Form   string `json:"form,omitempty"`
+}
+
+// RecordedResponse is a response with the session IDs redacted.
+// Bodies, which are not valid UTF-8, are stored base64 encoded.
+type RecordedResponse struct {
+	StatusCode int               `json:"status"`
+	Header     map[string]string `json:"header,omitempty"`
+	Body       string            `json:"body"`
+	Base64     bool              `json:"base64,omitempty"`
+}
+
+// Recorder is an http.RoundTripper, which records the exchanges with a
+// QNAP system, so they can be replayed by a Replayer in offline tests:
+//
+//	rec := filestationtest.NewRecorder(nil)
+//	session, err := filestation.Connect(host, user, pwd, &filestation.ConfigOptions{Transport: rec})
+//	...
+//	err = rec.Save("testdata/qts-5.1.json")
+//
+// ConfigOptions.IgnoreInvalidSSLCertificate does not apply to the recorded
+// requests. For a QNAP system with a self-signed certificate, pass a
+// transport which skips the verification:
+//
+//	transport := http.DefaultTransport.(*http.Transport).Clone()
+//	transport.TLSClientConfig = &tls.Config{InsecureSkipVerify: true}
+//	rec := filestationtest.NewRecorder(transport)
+type Recorder struct {
+	transport http.RoundTripper
+
+	mu        sync.Mutex
+	exchanges []Exchange
+}
+
+// NewRecorder creates a recorder, which forwards the requests to the
+// transport. A nil transport uses http.DefaultTransport, which verifies
+// the certificate of the QNAP system.
+func NewRecorder(transport http.RoundTripper) *Recorder {
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+
+	return &Recorder{transport: transport}
+}
+
+// RoundTrip forwards the request and records the exchange.
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	recorded, body, err := recordRequest(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if body != nil {
+		req = req.Clone(req.Context())
+		req.Body = io.NopCloser(bytes.NewReader(body))
+	}
+
+	res, err := r.transport.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	content, err := io.ReadAll(res.Body)
+	res.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	res.Body = io.NopCloser(bytes.NewReader(content))
+
+	exchange := Exchange{
+		Request:  recorded,
+		Response: recordResponse(res, content),
+	}
+
+	r.mu.Lock()
+	r.exchanges = append(r.exchanges, exchange)
+	r.mu.Unlock()
+
+	return res, nil
+}
+
+// Exchanges returns the exchanges recorded so far.
+func (r *Recorder) Exchanges() []Exchange {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return append([]Exchange(nil), r.exchanges...)
+}
+
+// Save writes the exchanges recorded so far into a fixture file.
+func (r *Recorder) Save(filename string) error {
+	data, err := json.MarshalIndent(r.Exchanges(), "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(filename, append(data, '\n'), 0666)
+}
+
+// Replayer is an http.RoundTripper, which serves recorded exchanges.
+// Every request is answered by the first unused exchange with the same
+// method, path, query and form; credentials and session IDs are ignored.
+// Requests without a matching exchange fail.
+type Replayer struct {
+	mu        sync.Mutex
+	exchanges []Exchange
+	used      []bool
+}
+
+// NewReplayer creates a replayer serving the exchanges.
+func NewReplayer(exchanges []Exchange) *Replayer {
+	return &Replayer{
+		exchanges: exchanges,
+		used:      make([]bool, len(exchanges)),
+	}
+}
+
+// LoadReplayer creates a replayer serving the exchanges of a fixture
+// file written by Recorder.Save().
+func LoadReplayer(filename string) (*Replayer, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	var exchanges []Exchange
+	if err := json.Unmarshal(data, &exchanges); err != nil {
+		return nil, fmt.Errorf("failed to parse recording %v: %w", filename, err)
+	}
+
+	return NewReplayer(exchanges), nil
+}
+
+// RoundTrip answers the request from the recorded exchanges.
+func (r *Replayer) RoundTrip(req *http.Request) (*http.Response, error) {
+	recorded, _, err := recordRequest(req)
+	if err != nil {
+		return nil, err
+	}
+	if req.Body != nil {
+		req.Body.Close()
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i, e := range r.exchanges {
+		if r.used[i] || e.Request != recorded {
+			continue
+		}
+
+		r.used[i] = true
+
+		return e.Response.response(req)
+	}
+
+	return nil, fmt.Errorf("no recorded exchange for %v %v", recorded.Method, recorded.URL)
+}
+
+// Remaining returns the number of exchanges, which have not been replayed.
+func (r *Replayer) Remaining() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	n := 0
+	for _, used := range r.used {
+		if !used {
+			n++
+		}
+	}
+	return n
+}
+
+// recordRequest converts the request into its redacted form. The body is
+// returned if it has been read, so it can be passed on.
+func recordRequest(req *http.Request) (RecordedRequest, []byte, error) {
+	query := req.URL.Query()
+	redactValues(query)
+
+	u := url.URL{Path: req.URL.Path, RawQuery: query.Encode()}
+
+	recorded := RecordedRequest{
+		Method: req.Method,
+		URL:    u.String(),
+	}
+
+	if req.Body == nil || !strings.HasPrefix(req.Header.Get("Content-Type"), "application/x-www-form-urlencoded") {
+		return recorded, nil, nil
+	}
+
+	body, err := io.ReadAll(req.Body)
+	req.Body.Close()
+	if err != nil {
+		return recorded, nil, err
+	}
+
+	form, err := url.ParseQuery(string(body))
+	if err != nil {
+		return recorded, nil, err
+	}
+	redactValues(form)
+
+	recorded.Form = form.Encode()
+
+	return recorded, body, nil
+}
+
+func recordResponse(res *http.Response, content []byte) RecordedResponse {
+	recorded := RecordedResponse{
+		StatusCode: res.StatusCode,
+		Header:     make(map[string]string),
+		Body:       string(redactBody(content)),
+	}
+
+	for _, h := range recordedHeaders {
+		if v := res.Header.Get(h); v != "" {
+			recorded.Header[h] = v
+		}
+	}
+
+	if !utf8.Valid(content) {
+		recorded.Body = base64.StdEncoding.EncodeToString(content)
+		recorded.Base64 = true
+	}
+
+	return recorded
+}
+
+func (r *RecordedResponse) response(req *http.Request) (*http.Response, error) {
+	body := []byte(r.Body)
+	if r.Base64 {
+		var err error
+		if body, err = base64.StdEncoding.DecodeString(r.Body); err != nil {
+			return nil, err
+		}
+	}
+
+	header := make(http.Header)
+	for k, v := range r.Header {
+		header.Set(k, v)
+	}
+
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", r.StatusCode, http.StatusText(r.StatusCode)),
+		StatusCode:    r.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}, nil
+}
+
+func redactValues(values url.Values) {
+	for _, p := range redactedParams {
+		if _, ok := values[p]; ok {
+			values.Set(p, redactedValue)
+		}
+	}
+}
+
+// redactBody replaces the session IDs within a JSON document, e.g. the
+// response of the login, at any nesting level. Any other content is
+// returned unchanged.
+func redactBody(content []byte) []byte {
+	trimmed := bytes.TrimSpace(content)
+	if len(trimmed) == 0 || (trimmed[0] != '{' && trimmed[0] != '[') {
+		return content
+	}
+
+	var doc interface{}
+	dec := json.NewDecoder(bytes.NewReader(trimmed))
+	dec.UseNumber()
+	if err := dec.Decode(&doc); err != nil {
+		return content
+	}
+
+	if !redactJSON(doc) {
+		return content
+	}
+
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(doc); err != nil {
+		return content
+	}
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n"))
+}
+
+// redactJSON replaces the values of the redacted fields within the
+// decoded JSON value in-place. It reports whether any has been replaced.
+func redactJSON(v interface{}) bool {
+	redacted := false
+
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for key, value := range v {
+			if isRedactedParam(key) {
+				v[key] = redactedValue
+				redacted = true
+			} else if redactJSON(value) {
+				redacted = true
+			}
+		}
+	case []interface{}:
+		for _, value := range v {
+			if redactJSON(value) {
+				redacted = true
+			}
+		}
+	}
+
+	return redacted
+}
+
+func isRedactedParam(name string) bool {
+	for _, p := range redactedParams {
+		if p == name {
+			return true
+		}
+	}
+	return false
+}
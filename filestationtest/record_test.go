@@ -0,0 +1,185 @@
+package filestationtest_test
+
+import (
+	"errors"
+	"github.com/nine-lives-later/go-qnap-filestation"
+	"github.com/nine-lives-later/go-qnap-filestation/filestationtest"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// recordedSession performs some calls, which are compared when replaying.
+func recordedSession(t *testing.T, s *filestation.FileStationSession) []string {
+	var ret []string
+
+	if _, err := s.CreateFolder("/share/folder"); err != nil {
+		t.Fatalf("Failed to create folder: %v", err)
+	}
+	if err := s.Upload("/share/folder/file.bin", strings.NewReader("\xff\xfe binary"), false); err != nil {
+		t.Fatalf("Failed to upload: %v", err)
+	}
+
+	list, err := s.GetFileList("/share/folder")
+	if err != nil {
+		t.Fatalf("Failed to list folder: %v", err)
+	}
+	for _, e := range list {
+		ret = append(ret, e.Name)
+	}
+
+	r, err := s.Download("/share/folder/file.bin")
+	if err != nil {
+		t.Fatalf("Failed to download: %v", err)
+	}
+	content, _ := io.ReadAll(r)
+	r.Close()
+	ret = append(ret, string(content))
+
+	if _, err := s.Download("/share/folder/missing.bin"); !errors.Is(err, fs.ErrNotExist) {
+		t.Fatalf("Expected missing file to fail: %v", err)
+	}
+
+	return ret
+}
+
+func TestRecordReplay(t *testing.T) {
+	srv := filestationtest.NewServer(t.TempDir())
+	defer srv.Close()
+
+	srv.AddUser("admin", "secret")
+	srv.AddShare("share", false)
+
+	// record
+	rec := filestationtest.NewRecorder(nil)
+
+	s, err := filestation.Connect(srv.URL, "admin", "secret", &filestation.ConfigOptions{Transport: rec})
+	if err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+	recorded := recordedSession(t, s)
+	s.Logout()
+
+	fixture := filepath.Join(t.TempDir(), "recording.json")
+	if err := rec.Save(fixture); err != nil {
+		t.Fatalf("Failed to save recording: %v", err)
+	}
+
+	data, _ := os.ReadFile(fixture)
+	if strings.Contains(string(data), "secret") || strings.Contains(string(data), "c2VjcmV0") || !strings.Contains(string(data), "REDACTED") {
+		t.Fatalf("Expected credentials to be redacted:\n%s", data)
+	}
+
+	// replay, without a server
+	srv.Close()
+
+	rep, err := filestationtest.LoadReplayer(fixture)
+	if err != nil {
+		t.Fatalf("Failed to load recording: %v", err)
+	}
+
+	s, err = filestation.Connect("qnap.invalid", "admin", "other-password", &filestation.ConfigOptions{Transport: rep})
+	if err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+	replayed := recordedSession(t, s)
+	s.Logout()
+
+	if strings.Join(recorded, "|") != strings.Join(replayed, "|") {
+		t.Fatalf("Replay differs: %q %q", recorded, replayed)
+	}
+	if rep.Remaining() != 0 {
+		t.Fatalf("Expected all exchanges to be replayed, %v remaining", rep.Remaining())
+	}
+
+	if _, err := s.GetShareList(); err == nil {
+		t.Fatal("Expected request without recording to fail")
+	}
+}
+
+func TestRecordListParams(t *testing.T) {
+	srv := filestationtest.NewServer(t.TempDir())
+	t.Cleanup(srv.Close)
+
+	srv.AddUser("admin", "secret")
+	srv.AddShare("share", false)
+
+	rec := filestationtest.NewRecorder(nil)
+
+	s, err := filestation.Connect(srv.URL, "admin", "secret", &filestation.ConfigOptions{Transport: rec})
+	if err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+	t.Cleanup(func() { s.Close() })
+
+	tests := []struct {
+		opts     filestation.ListOptions
+		expected url.Values
+	}{
+		{filestation.ListOptions{}, url.Values{}},
+		{filestation.ListOptions{SortBy: filestation.SortField_Size}, url.Values{"sort": {"filesize"}, "dir": {"ASC"}}},
+		{filestation.ListOptions{SortBy: filestation.SortField_ModTime, Descending: true}, url.Values{"sort": {"mt"}, "dir": {"DESC"}}},
+		{filestation.ListOptions{Descending: true}, url.Values{"sort": {"filename"}, "dir": {"DESC"}}},
+		{filestation.ListOptions{NameFilter: "report"}, url.Values{"filename": {"report"}}},
+	}
+
+	for _, tt := range tests {
+		before := len(rec.Exchanges())
+
+		if _, err := s.GetFileListWithOptions("/share", tt.opts); err != nil {
+			t.Fatalf("Failed to list folder: %v", err)
+		}
+
+		exchanges := rec.Exchanges()[before:]
+		if len(exchanges) != 1 {
+			t.Fatalf("Expected one request, got %v", len(exchanges))
+		}
+
+		u, err := url.Parse(exchanges[0].Request.URL)
+		if err != nil {
+			t.Fatalf("Failed to parse URL: %v", err)
+		}
+		query := u.Query()
+
+		for _, param := range []string{"sort", "dir", "filename"} {
+			if query.Get(param) != tt.expected.Get(param) {
+				t.Errorf("%+v: wrong %v parameter: %q", tt.opts, param, query.Get(param))
+			}
+		}
+	}
+}
+
+func TestRecordRedactNested(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"status":1,"data":{"sid":"s3cr3t-sid","items":[{"qtoken":"s3cr3t-token","size":12345678901}]}}`))
+	}))
+	t.Cleanup(srv.Close)
+
+	rec := filestationtest.NewRecorder(nil)
+
+	res, err := (&http.Client{Transport: rec}).Get(srv.URL + "/cgi-bin/filemanager/utilRequest.cgi?sid=s3cr3t-sid")
+	if err != nil {
+		t.Fatalf("Failed to send request: %v", err)
+	}
+	res.Body.Close()
+
+	exchanges := rec.Exchanges()
+	if len(exchanges) != 1 {
+		t.Fatalf("Expected one exchange, got %v", len(exchanges))
+	}
+
+	recorded := exchanges[0].Request.URL + exchanges[0].Response.Body
+	if strings.Contains(recorded, "s3cr3t") {
+		t.Fatalf("Expected nested fields to be redacted: %v", recorded)
+	}
+	if !strings.Contains(exchanges[0].Response.Body, `"size":12345678901`) {
+		t.Fatalf("Expected other fields to be unchanged: %v", exchanges[0].Response.Body)
+	}
+}
@@ -0,0 +1,233 @@
+// Package filestationtest provides utilities for testing code, which uses
+// the filestation package, without a QNAP system.
+package filestationtest
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"github.com/nine-lives-later/go-qnap-filestation"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Server is a fake File Station, which serves the sub-directories of a
+// local directory as shares. It implements wfm2Login.cgi, wfm2Logout.cgi
+// and the file functions of utilRequest.cgi, reporting errors the same
+// way as a QNAP system.
+//
+//	srv := filestationtest.NewServer(t.TempDir())
+//	defer srv.Close()
+//
+//	srv.AddUser("admin", "secret")
+//	srv.AddShare("Public", true)
+//
+//	session, err := filestation.Connect(srv.URL, "admin", "secret", nil)
+type Server struct {
+	*httptest.Server
+
+	// Root is the local directory. Every sub-directory is a share.
+	Root string
+
+	// Version and Build are reported as firmware version.
+	// They must not be changed while requests are served.
+	Version string
+	Build   string
+
+	// PasswordEncoding restricts the accepted encoding of the password.
+	// PasswordEncodingAuto accepts the plain and the base64 encoded password.
+	// It must not be changed while requests are served.
+	PasswordEncoding filestation.PasswordEncoding
+
+	// LegacyLogin only accepts the credentials as query parameters,
+	// like old firmware. Logins with form data are answered by HTTP 405.
+	// It must not be changed while requests are served.
+	LegacyLogin bool
+
+	mu          sync.Mutex
+	users       map[string]string
+	sessions    map[string]string
+	qtokens     map[string]string
+	recycleBins map[string]bool
+
+	faultMu sync.Mutex
+	faults  []*Fault
+}
+
+// NewServer starts a fake File Station serving the shares of the root
+// directory. The caller should call Close when finished, to shut it down.
+func NewServer(root string) *Server {
+	s := &Server{
+		Root:        root,
+		Version:     "4.3.6",
+		Build:       "20200109",
+		users:       make(map[string]string),
+		sessions:    make(map[string]string),
+		qtokens:     make(map[string]string),
+		recycleBins: make(map[string]bool),
+	}
+
+	s.Server = httptest.NewServer(s)
+
+	return s
+}
+
+// AddUser permits the user to log in.
+func (s *Server) AddUser(username, password string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.users[username] = password
+}
+
+// AddShare creates a new share. If recycleBin is set, deleted files and
+// folders are moved to the "@Recycle" folder of the share.
+func (s *Server) AddShare(name string, recycleBin bool) error {
+	if err := filestation.ValidateName(name); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := os.MkdirAll(filepath.Join(s.Root, name), 0777); err != nil {
+		return err
+	}
+
+	s.recycleBins[name] = recycleBin
+
+	return nil
+}
+
+// ServeHTTP handles the requests of the File Station API.
+// The requests are processed one after the other, after
+// applying the injected faults.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if s.injectFault(w, r) {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	switch r.URL.Path {
+	case "/cgi-bin/filemanager/wfm2Login.cgi":
+		if s.LegacyLogin && r.Method == http.MethodPost {
+			http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+			return
+		}
+		s.login(w, r)
+	case "/cgi-bin/filemanager/wfm2Logout.cgi":
+		s.logout(w, r)
+	case "/cgi-bin/filemanager/utilRequest.cgi":
+		user, ok := s.sessions[r.FormValue("sid")]
+		if !ok {
+			writeStatus(w, filestation.WFM2_AUTH_FAIL)
+			return
+		}
+
+		s.utilRequest(w, r, user)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+type loginResponse struct {
+	Status     filestation.FileStationStatus `json:"status"`
+	Version    string                        `json:"version,omitempty"`
+	Build      string                        `json:"build,omitempty"`
+	SessionID  string                        `json:"sid,omitempty"`
+	QToken     string                        `json:"qtoken,omitempty"`
+	AdminGroup int                           `json:"admingroup,omitempty"`
+}
+
+// login authenticates the user by password or by a login token. On QTS 5.x,
+// a login token is issued if requested by "remme".
+func (s *Server) login(w http.ResponseWriter, r *http.Request) {
+	result := loginResponse{
+		Status:  filestation.WFM2_FAIL,
+		Version: s.Version,
+		Build:   s.Build,
+	}
+
+	user := r.FormValue("user")
+	ok := false
+	if token := r.FormValue("qtoken"); token != "" {
+		ok = user != "" && s.qtokens[token] == user
+	} else {
+		ok = s.checkPassword(user, r.FormValue("pwd"))
+	}
+
+	if ok {
+		sid := newSessionID()
+		s.sessions[sid] = user
+
+		result.Status = filestation.WFM2_SUCCESS
+		result.SessionID = sid
+		result.AdminGroup = 1
+
+		if r.FormValue("remme") == "1" && majorVersion(s.Version) >= 5 {
+			result.QToken = newSessionID()
+			s.qtokens[result.QToken] = user
+		}
+	}
+
+	writeJSON(w, result)
+}
+
+// checkPassword verifies the password, which is either
+// sent plain or base64 encoded.
+func (s *Server) checkPassword(user, pwd string) bool {
+	password, ok := s.users[user]
+	if !ok || user == "" {
+		return false
+	}
+
+	switch s.PasswordEncoding {
+	case filestation.PasswordEncodingPlain:
+		return pwd == password
+	case filestation.PasswordEncodingBase64:
+		return pwd == base64.StdEncoding.EncodeToString([]byte(password))
+	}
+
+	return pwd == password || pwd == base64.StdEncoding.EncodeToString([]byte(password))
+}
+
+// majorVersion returns the major version of the firmware, e.g. 5 for "5.0.1".
+func majorVersion(version string) int {
+	major, _ := strconv.Atoi(strings.SplitN(version, ".", 2)[0])
+	return major
+}
+
+func (s *Server) logout(w http.ResponseWriter, r *http.Request) {
+	delete(s.sessions, r.FormValue("sid"))
+
+	writeStatus(w, filestation.WFM2_SUCCESS)
+}
+
+func newSessionID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return hex.EncodeToString(b)
+}
+
+type statusResponse struct {
+	Status filestation.FileStationStatus `json:"status"`
+}
+
+func writeStatus(w http.ResponseWriter, status filestation.FileStationStatus) {
+	writeJSON(w, statusResponse{Status: status})
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,359 @@
+package filestationtest_test
+
+import (
+	"context"
+	"errors"
+	"github.com/nine-lives-later/go-qnap-filestation"
+	"github.com/nine-lives-later/go-qnap-filestation/filestationtest"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func createTestServer(t *testing.T) (*filestationtest.Server, *filestation.FileStationSession) {
+	srv := filestationtest.NewServer(t.TempDir())
+	t.Cleanup(srv.Close)
+
+	// QTS 5.x issues a login token for renewing expired sessions
+	srv.Version = "5.0.1"
+	srv.AddUser("admin", "secret")
+	if err := srv.AddShare("share", true); err != nil {
+		t.Fatalf("Failed to create share: %v", err)
+	}
+	if err := srv.AddShare("nobin", false); err != nil {
+		t.Fatalf("Failed to create share: %v", err)
+	}
+
+	s, err := filestation.Connect(srv.URL, "admin", "secret", nil)
+	if err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+	t.Cleanup(func() { s.Close() })
+
+	return srv, s
+}
+
+func TestServerLogin(t *testing.T) {
+	srv, s := createTestServer(t)
+
+	if s.ServerInfo().Version != srv.Version {
+		t.Fatalf("Wrong version: %v", s.ServerInfo().Version)
+	}
+	if !s.ServerInfo().AdminGroup {
+		t.Fatal("Expected user to be in the admin group")
+	}
+
+	_, err := filestation.Connect(srv.URL, "admin", "wrong", nil)
+	if !errors.Is(err, filestation.WFM2_FAIL) {
+		t.Fatalf("Expected login to fail: %v", err)
+	}
+}
+
+func TestServerLoginHTTPError(t *testing.T) {
+	srv := filestationtest.NewServer(t.TempDir())
+	t.Cleanup(srv.Close)
+
+	srv.AddUser("admin", "secret")
+	srv.InjectFault(filestationtest.Fault{Endpoint: "wfm2Login.cgi", Type: filestationtest.FaultType_HTTPError})
+
+	// a failing form login must not be repeated with the password in the URL
+	for _, legacy := range []bool{false, true} {
+		rec := filestationtest.NewRecorder(nil)
+		if _, err := filestation.Connect(srv.URL, "admin", "secret", &filestation.ConfigOptions{Transport: rec, LegacyGETLogin: legacy}); err == nil {
+			t.Fatalf("legacy %v: Expected login to fail", legacy)
+		}
+
+		for _, e := range rec.Exchanges() {
+			if strings.Contains(e.Request.URL, "pwd=") {
+				t.Fatalf("legacy %v: Password sent as query parameter: %v", legacy, e.Request.URL)
+			}
+		}
+	}
+}
+
+func TestServerLegacyLogin(t *testing.T) {
+	srv := filestationtest.NewServer(t.TempDir())
+	t.Cleanup(srv.Close)
+
+	srv.LegacyLogin = true
+	srv.AddUser("admin", "secret")
+
+	// the query parameters are only used if permitted
+	if _, err := filestation.Connect(srv.URL, "admin", "secret", nil); err == nil {
+		t.Fatal("Expected login to fail without LegacyGETLogin")
+	}
+
+	s, err := filestation.Connect(srv.URL, "admin", "secret", &filestation.ConfigOptions{LegacyGETLogin: true})
+	if err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+	t.Cleanup(func() { s.Close() })
+
+	if s.Supports(filestation.CapabilityPOSTLogin) {
+		t.Fatal("Expected form login not to be supported")
+	}
+}
+
+func TestServerLoginRequests(t *testing.T) {
+	srv := filestationtest.NewServer(t.TempDir())
+	t.Cleanup(srv.Close)
+
+	srv.AddUser("admin", "secret")
+
+	// no unauthenticated login is sent, which counts as failed attempt
+	rec := filestationtest.NewRecorder(nil)
+	s, err := filestation.Connect(srv.URL, "admin", "secret", &filestation.ConfigOptions{Transport: rec})
+	if err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+	t.Cleanup(func() { s.Close() })
+
+	if n := len(rec.Exchanges()); n != 1 {
+		t.Fatalf("Expected a single login request, got %v", n)
+	}
+
+	// the plain password is never sent as query parameter
+	legacy := filestationtest.NewServer(t.TempDir())
+	t.Cleanup(legacy.Close)
+
+	legacy.LegacyLogin = true
+	legacy.AddUser("admin", "secret")
+
+	rec = filestationtest.NewRecorder(nil)
+	if _, err := filestation.Connect(legacy.URL, "admin", "secret", &filestation.ConfigOptions{
+		Transport:        rec,
+		LegacyGETLogin:   true,
+		PasswordEncoding: filestation.PasswordEncodingPlain,
+	}); err == nil {
+		t.Fatal("Expected login to fail")
+	}
+	for _, e := range rec.Exchanges() {
+		if strings.Contains(e.Request.URL, "pwd=") {
+			t.Fatalf("Password sent as query parameter: %v", e.Request.URL)
+		}
+	}
+}
+
+func TestServerPasswordEncoding(t *testing.T) {
+	tests := []struct {
+		version  string
+		accepted filestation.PasswordEncoding
+		override filestation.PasswordEncoding
+		success  bool
+	}{
+		{"4.3.6", filestation.PasswordEncodingBase64, filestation.PasswordEncodingAuto, true},
+		{"4.3.6", filestation.PasswordEncodingPlain, filestation.PasswordEncodingAuto, false},
+		{"4.3.6", filestation.PasswordEncodingPlain, filestation.PasswordEncodingPlain, true},
+		{"5.0.1", filestation.PasswordEncodingPlain, filestation.PasswordEncodingAuto, false},
+		{"5.0.1", filestation.PasswordEncodingPlain, filestation.PasswordEncodingPlain, true},
+		{"5.0.1", filestation.PasswordEncodingBase64, filestation.PasswordEncodingAuto, true},
+		{"5.0.1", filestation.PasswordEncodingBase64, filestation.PasswordEncodingBase64, true},
+	}
+
+	for _, tt := range tests {
+		srv := filestationtest.NewServer(t.TempDir())
+		srv.Version = tt.version
+		srv.PasswordEncoding = tt.accepted
+		srv.AddUser("admin", "secret")
+
+		s, err := filestation.Connect(srv.URL, "admin", "secret", &filestation.ConfigOptions{PasswordEncoding: tt.override})
+		if tt.success && err != nil {
+			t.Errorf("%v %v %v: Failed to connect: %v", tt.version, tt.accepted, tt.override, err)
+		}
+		if !tt.success && !errors.Is(err, filestation.WFM2_FAIL) {
+			t.Errorf("%v %v %v: Expected login to fail: %v", tt.version, tt.accepted, tt.override, err)
+		}
+		if err == nil {
+			s.Close()
+		}
+
+		srv.Close()
+	}
+}
+
+func TestServerQTokenRelogin(t *testing.T) {
+	srv := filestationtest.NewServer(t.TempDir())
+	t.Cleanup(srv.Close)
+
+	srv.Version = "5.0.1"
+	srv.AddUser("admin", "secret")
+
+	rec := filestationtest.NewRecorder(nil)
+	s, err := filestation.Connect(srv.URL, "admin", "secret", &filestation.ConfigOptions{Transport: rec, RememberPassword: true})
+	if err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+	t.Cleanup(func() { s.Close() })
+
+	if !s.Supports(filestation.CapabilityQToken) {
+		t.Fatal("Expected a login token to be issued")
+	}
+
+	// the token is used for relogin
+	if err := s.Relogin(); err != nil {
+		t.Fatalf("Failed to relogin: %v", err)
+	}
+
+	// a rejected form login must not send the token as query parameter
+	srv.InjectFault(filestationtest.Fault{Endpoint: "wfm2Login.cgi", Times: 1, Type: filestationtest.FaultType_HTTPError})
+
+	if err := s.Relogin(); err != nil {
+		t.Fatalf("Expected relogin by password: %v", err)
+	}
+
+	for _, e := range rec.Exchanges() {
+		if strings.Contains(e.Request.URL, "qtoken") || strings.Contains(e.Request.URL, "pwd=") {
+			t.Fatalf("Credentials sent as query parameter: %v", e.Request.URL)
+		}
+	}
+}
+
+func TestServerRecycleBin(t *testing.T) {
+	srv, s := createTestServer(t)
+
+	if _, err := s.EnsureFolder("/share/a/b"); err != nil {
+		t.Fatalf("Failed to create folder: %v", err)
+	}
+	if err := s.Upload("/share/a/b/file.txt", strings.NewReader("data"), false); err != nil {
+		t.Fatalf("Failed to upload: %v", err)
+	}
+
+	result, err := s.Delete("/share/a/b/file.txt", false)
+	if err != nil || result != filestation.DeleteResult_MovedToRecycleBin {
+		t.Fatalf("Wrong result: %v %v", result, err)
+	}
+
+	content, err := os.ReadFile(filepath.Join(srv.Root, "share", "@Recycle", "a", "b", "file.txt"))
+	if err != nil || string(content) != "data" {
+		t.Fatalf("Expected file in the recycle bin: %q %v", content, err)
+	}
+
+	if deleted, err := s.DeleteFile("/share/a/missing"); err != nil || deleted {
+		t.Fatalf("Expected missing file not to be deleted: %v %v", deleted, err)
+	}
+	if _, err := s.DeleteFile("/share/missing/a"); !errors.Is(err, fs.ErrNotExist) {
+		t.Fatalf("Expected missing folder to fail: %v", err)
+	}
+}
+
+func TestServerRecycleBinCached(t *testing.T) {
+	srv, _ := createTestServer(t)
+
+	rec := filestationtest.NewRecorder(nil)
+	s, err := filestation.Connect(srv.URL, "admin", "secret", &filestation.ConfigOptions{Transport: rec})
+	if err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+	t.Cleanup(func() { s.Close() })
+
+	// the recycle bin settings are retrieved once for all shares
+	for _, p := range []string{"/share/a", "/share/b", "/nobin/a"} {
+		if _, err := s.CreateFolder(p); err != nil {
+			t.Fatalf("Failed to create folder: %v", err)
+		}
+	}
+
+	expected := []filestation.DeleteResult{filestation.DeleteResult_MovedToRecycleBin, filestation.DeleteResult_MovedToRecycleBin, filestation.DeleteResult_Deleted}
+	for i, p := range []string{"/share/a", "/share/b", "/nobin/a"} {
+		if result, err := s.Delete(p, false); err != nil || result != expected[i] {
+			t.Fatalf("Wrong result for %v: %v %v", p, result, err)
+		}
+	}
+
+	trees := 0
+	for _, e := range rec.Exchanges() {
+		if strings.Contains(e.Request.URL, "func=get_tree") {
+			trees++
+		}
+	}
+	if trees != 1 {
+		t.Fatalf("Expected share list to be retrieved once, got %v", trees)
+	}
+}
+
+func TestServerFiles(t *testing.T) {
+	_, s := createTestServer(t)
+
+	s.EnsureFolder("/nobin/a/b")
+	if err := s.Upload("/nobin/a/file.txt", strings.NewReader("hello"), false); err != nil {
+		t.Fatalf("Failed to upload: %v", err)
+	}
+	if err := s.Upload("/nobin/a/file.txt", strings.NewReader("again"), false); !errors.Is(err, fs.ErrExist) {
+		t.Fatalf("Expected upload without overwrite to fail: %v", err)
+	}
+	if err := s.Move("/nobin/a/file.txt", "/nobin/a/b"); err != nil {
+		t.Fatalf("Failed to move: %v", err)
+	}
+	if err := s.Rename("/nobin/a/b/file.txt", "renamed.txt"); err != nil {
+		t.Fatalf("Failed to rename: %v", err)
+	}
+
+	r, err := s.Download("/nobin/a/b/renamed.txt")
+	if err != nil {
+		t.Fatalf("Failed to download: %v", err)
+	}
+	content, _ := io.ReadAll(r)
+	r.Close()
+	if string(content) != "hello" {
+		t.Fatalf("Wrong content: %q", content)
+	}
+
+	if _, err := s.Download("/nobin/a/missing.txt"); !errors.Is(err, fs.ErrNotExist) {
+		t.Fatalf("Expected missing file to fail: %v", err)
+	}
+
+	tree, err := s.GetFolderTree(context.Background(), "/", 3)
+	if err != nil || len(tree.Children) != 2 || tree.Children[0].Path != "/nobin" || tree.Children[1].RecycleBin != "1" {
+		t.Fatalf("Wrong tree: %+v %v", tree, err)
+	}
+	if b := tree.Children[0].Children[0].Children[0]; b.Path != "/nobin/a/b" || b.ItemCount != 1 {
+		t.Fatalf("Wrong tree node: %+v", b)
+	}
+}
+
+func TestServerRelogin(t *testing.T) {
+	_, s := createTestServer(t)
+
+	if err := s.Logout(); err != nil {
+		t.Fatalf("Failed to logout: %v", err)
+	}
+
+	// a closed session is not renewed
+	if _, err := s.GetShareList(); !errors.Is(err, filestation.WFM2_AUTH_FAIL) {
+		t.Fatalf("Expected closed session to fail: %v", err)
+	}
+	if err := s.Relogin(); err == nil {
+		t.Fatal("Expected relogin of closed session to fail")
+	}
+}
+
+func TestServerRelogin_Password(t *testing.T) {
+	srv := filestationtest.NewServer(t.TempDir())
+	t.Cleanup(srv.Close)
+
+	srv.AddUser("admin", "secret")
+
+	// without login token, the password is only used if remembered
+	s, err := filestation.Connect(srv.URL, "admin", "secret", nil)
+	if err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+	t.Cleanup(func() { s.Close() })
+
+	if err := s.Relogin(); err == nil {
+		t.Fatal("Expected relogin to fail without password")
+	}
+
+	s2, err := filestation.Connect(srv.URL, "admin", "secret", &filestation.ConfigOptions{RememberPassword: true})
+	if err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+	t.Cleanup(func() { s2.Close() })
+
+	if err := s2.Relogin(); err != nil {
+		t.Fatalf("Failed to relogin: %v", err)
+	}
+}
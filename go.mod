@@ -1,6 +1,6 @@
 module github.com/nine-lives-later/go-qnap-filestation
 
-go 1.14
+go 1.16
 
 require (
 	github.com/go-resty/resty/v2 v2.3.0
@@ -1,8 +1,42 @@
 package filestation
 
+import "net/url"
+
 func boolToIntStr(b bool) string {
 	if b {
 		return "1"
 	}
 	return "0"
 }
+
+// redactedParams lists the query parameters that must never be logged.
+var redactedParams = []string{"pwd", "sid", "qtoken"}
+
+// RedactedParams returns the names of the request parameters and response
+// fields, which contain credentials or session IDs. Their values are
+// replaced in request logs and should be replaced in any other record.
+func RedactedParams() []string {
+	return append([]string(nil), redactedParams...)
+}
+
+// redactURL returns the URL as string with all credentials
+// and session IDs replaced.
+func redactURL(u *url.URL) string {
+	if u == nil {
+		return ""
+	}
+
+	r := *u
+	query := r.Query()
+
+	for _, p := range redactedParams {
+		if _, ok := query[p]; ok {
+			query.Set(p, "REDACTED")
+		}
+	}
+
+	r.RawQuery = query.Encode()
+	r.User = nil
+
+	return r.String()
+}
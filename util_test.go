@@ -0,0 +1,22 @@
+package filestation
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestRedactURL(t *testing.T) {
+	u, err := url.Parse("https://storage:8443/cgi-bin/filemanager/wfm2Login.cgi?user=admin&pwd=YWRtaW4%3D&sid=abc123")
+	if err != nil {
+		t.Fatalf("Failed to parse URL: %v", err)
+	}
+
+	r := redactURL(u)
+	if strings.Contains(r, "YWRtaW4") || strings.Contains(r, "abc123") {
+		t.Fatalf("URL not redacted: %v", r)
+	}
+	if !strings.Contains(r, "user=admin") {
+		t.Fatalf("URL redacted too much: %v", r)
+	}
+}